@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
 )
 
 func ParseFailureExpression(expression string) (string, map[string]int, error) {
@@ -29,7 +31,7 @@ func ParseFailureExpression(expression string) (string, map[string]int, error) {
 
 	// If user expression is empty then return default threshold limits.
 	if expression == "" {
-		return "OR", map[string]int{"CRITICAL": 1, "HIGH": 1, "MEDIUM": 1, "LOW": 1}, nil
+		return "OR", map[string]int{"CRITICAL": 1, "HIGH": 1, "MEDIUM": 1, "LOW": 1, "UNKNOWN": 1}, nil
 	}
 
 	var operator = ""
@@ -89,14 +91,16 @@ func validateOperator(finalOperator, expressionOperator string) (string, error)
 	return "", fmt.Errorf("invalid operator: %v", finalOperator)
 }
 
-func validateSeverity(severity string, severityCount int) error {
+func validateSeverity(s string, severityCount int) error {
 	if severityCount < 0 {
 		return fmt.Errorf("validation expression can not have negative values")
 	}
 
-	if severity == "CRITICAL" || severity == "HIGH" || severity == "MEDIUM" || severity == "LOW" {
+	// UNKNOWN only ever has violations when unrecognised severities are
+	// counted under it, see severity.MODE_UNKNOWN.
+	if severity.IsKnown(s) || s == severity.UNKNOWN {
 		return nil
 	}
 
-	return fmt.Errorf("invalid severity expression: %s", severity)
+	return fmt.Errorf("invalid severity expression: %s", s)
 }
@@ -111,6 +111,7 @@ func TestProcessExpression(t *testing.T) {
 				"HIGH":     1,
 				"MEDIUM":   1,
 				"LOW":      1,
+				"UNKNOWN":  1,
 			},
 			expectedOperator: "OR",
 			expectedError:    false,
@@ -202,6 +203,12 @@ func TestValidateSeverity(t *testing.T) {
 			severityCount: 1,
 			wantError:     false,
 		},
+		{
+			name:          "UnknownSeverity_Succeeds",
+			severity:      "UNKNOWN",
+			severityCount: 1,
+			wantError:     false,
+		},
 		{
 			name:          "UndefinedSeverity_Error",
 			severity:      "Undefined",
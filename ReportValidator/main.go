@@ -26,12 +26,15 @@ import (
 
 	"github.com/google/gcp-scc-iac-validation-utils/ReportValidator/expressionprocessor"
 	"github.com/google/gcp-scc-iac-validation-utils/ReportValidator/validator"
-	"github.com/google/gcp-scc-iac-validation-utils/templates"
+	"github.com/google/gcp-scc-iac-validation-utils/loader"
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
 )
 
 var (
-	inputFilePath      = flag.String("inputFilePath", "", "path of the json file")
+	inputFilePath      = flag.String("inputFilePath", "", "path of the report or of a SARIF 2.1.0 log in JSON or YAML, optionally gzip compressed, or - for stdin")
 	failure_expression = flag.String("failure_expression", "", "condition for validation")
+	strict             = flag.Bool("strict", false, "reject reports with unknown fields, wrong types or missing violation fields")
+	unknown_severity   = flag.String("unknown_severity", "fail", "handling of severities other than critical, high, medium and low: fail, unknown or map:<SEVERITY>")
 )
 
 func main() {
@@ -43,13 +46,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	report, err := readAndParseIACScanReport(inputFilePath)
+	severityPolicy, err := severity.ParsePolicy(*unknown_severity)
 	if err != nil {
-		fmt.Printf("Failure while reading and parsing IAC scan report: %v", err)
+		fmt.Printf("Failure while processing the unknown_severity: %v", err)
 		os.Exit(1)
 	}
 
-	isBreachingThreshold, err := validator.EvaluateIACScanReport(report, thresholds, operator)
+	isBreachingThreshold, err := evaluateInput(*inputFilePath, thresholds, operator, severityPolicy)
 	if err != nil {
 		fmt.Printf("Failure occured during validation: %v", err)
 		os.Exit(1)
@@ -63,16 +66,27 @@ func main() {
 	fmt.Println("Validation Succeeded!")
 }
 
-func readAndParseIACScanReport(inputFilePath *string) (templates.IACReportTemplate, error) {
-	data, err := os.ReadFile(*inputFilePath)
+// evaluateInput evaluates SARIF logs, of SARIFConverter or any other tool, as
+// well as IaC validation reports.
+func evaluateInput(filePath string, thresholds map[string]int, operator string, severityPolicy severity.Policy) (bool, error) {
+	data, err := loader.ReadInput(filePath)
 	if err != nil {
-		return templates.IACReportTemplate{}, fmt.Errorf("os.ReadFile(%s): %v", *inputFilePath, err)
+		return false, fmt.Errorf("loader.ReadInput: %v", err)
 	}
 
-	var iacReport templates.IACReportTemplate
-	if err = json.Unmarshal(data, &iacReport); err != nil {
-		return templates.IACReportTemplate{}, fmt.Errorf("json.Unmarshal(): %v", err)
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return false, fmt.Errorf("json.Unmarshal: %v", err)
 	}
 
-	return iacReport, nil
+	if loader.DetectShape(document) == loader.SHAPE_SARIF {
+		return validator.EvaluateSarifReport(data, thresholds, operator, severityPolicy)
+	}
+
+	reports, err := loader.ParseIACScanReports(data, loader.Options{Strict: *strict})
+	if err != nil {
+		return false, fmt.Errorf("loader.ParseIACScanReports: %v", err)
+	}
+
+	return validator.EvaluateIACScanReport(loader.Merge(reports), thresholds, operator, severityPolicy)
 }
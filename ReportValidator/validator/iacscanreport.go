@@ -22,11 +22,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
 	"github.com/google/gcp-scc-iac-validation-utils/templates"
 )
 
-func EvaluateIACScanReport(iacReport templates.IACReportTemplate, thresholds map[string]int, operator string) (bool, error) {
-	severityCounts, err := fetchViolationFromIACReport(iacReport)
+func EvaluateIACScanReport(iacReport templates.IACReportTemplate, thresholds map[string]int, operator string, severityPolicy severity.Policy) (bool, error) {
+	severityCounts, err := fetchViolationFromIACReport(iacReport, severityPolicy)
 	if err != nil {
 		return false, fmt.Errorf("fetchVoilationFromIACReport(): %v", err)
 	}
@@ -90,16 +91,16 @@ func any(failureCriteriaViolations map[string]bool) bool {
 	return false
 }
 
-func fetchViolationFromIACReport(iacReport templates.IACReportTemplate) (map[string]int, error) {
+func fetchViolationFromIACReport(iacReport templates.IACReportTemplate, severityPolicy severity.Policy) (map[string]int, error) {
 	severityCounts := make(map[string]int)
 
 	for _, v := range iacReport.Response.IacValidationReport.Violations {
-		s := strings.ToUpper(v.Severity)
-		if !(s == "CRITICAL" || s == "HIGH" || s == "MEDIUM" || s == "LOW") {
-			return nil, fmt.Errorf("invalid severity expression: %s", s)
+		s, err := severity.Normalize(v.Severity, severityPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("severity.Normalize: %v", err)
 		}
 
-		severityCounts[strings.ToUpper(v.Severity)]++
+		severityCounts[s]++
 	}
 
 	return severityCounts, nil
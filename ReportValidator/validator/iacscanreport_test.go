@@ -21,6 +21,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
 	"github.com/google/gcp-scc-iac-validation-utils/templates"
 )
 
@@ -30,6 +31,7 @@ func TestEvaluateIACScanReport(t *testing.T) {
 		iacReport            templates.IACReportTemplate
 		threshold            map[string]int
 		operator             string
+		severityPolicy       severity.Policy
 		isBreachingThreshold bool
 		wantErr              bool
 	}{
@@ -174,11 +176,46 @@ func TestEvaluateIACScanReport(t *testing.T) {
 			isBreachingThreshold: false,
 			wantErr:              true,
 		},
+		{
+			name: "UnspecifiedSeverity_MappedToHigh_Succeeds",
+			iacReport: templates.IACReportTemplate{
+				Response: templates.Responses{
+					IacValidationReport: templates.IACValidationReport{
+						Violations: []templates.Violation{
+							{Severity: "SEVERITY_UNSPECIFIED"},
+						},
+					},
+				},
+			},
+			threshold:            map[string]int{"HIGH": 1},
+			operator:             "OR",
+			severityPolicy:       severity.Policy{Mode: severity.MODE_MAP, MapTo: severity.HIGH},
+			isBreachingThreshold: true,
+			wantErr:              false,
+		},
+		{
+			name: "UnspecifiedSeverity_CountedAsUnknown_Succeeds",
+			iacReport: templates.IACReportTemplate{
+				Response: templates.Responses{
+					IacValidationReport: templates.IACValidationReport{
+						Violations: []templates.Violation{
+							{Severity: "SEVERITY_UNSPECIFIED"},
+							{Severity: "informational"},
+						},
+					},
+				},
+			},
+			threshold:            map[string]int{"UNKNOWN": 2},
+			operator:             "OR",
+			severityPolicy:       severity.Policy{Mode: severity.MODE_UNKNOWN},
+			isBreachingThreshold: true,
+			wantErr:              false,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := EvaluateIACScanReport(test.iacReport, test.threshold, test.operator)
+			got, err := EvaluateIACScanReport(test.iacReport, test.threshold, test.operator, test.severityPolicy)
 			if (err != nil) != test.wantErr {
 				t.Errorf("Expected error: %v, got: %v", test.wantErr, err)
 			}
@@ -394,10 +431,11 @@ func TestAny(t *testing.T) {
 
 func TestFetchViolationFromIACReport(t *testing.T) {
 	tests := []struct {
-		name     string
-		report   templates.IACReportTemplate
-		expected map[string]int
-		wantErr  bool
+		name           string
+		report         templates.IACReportTemplate
+		severityPolicy severity.Policy
+		expected       map[string]int
+		wantErr        bool
 	}{
 		{
 			name: "ValidReport_Succeeds",
@@ -441,11 +479,46 @@ func TestFetchViolationFromIACReport(t *testing.T) {
 			expected: nil,
 			wantErr:  true,
 		},
+		{
+			name: "LowercaseSeverity_Succeeds",
+			report: templates.IACReportTemplate{
+				Response: templates.Responses{
+					IacValidationReport: templates.IACValidationReport{
+						Violations: []templates.Violation{
+							{Severity: "high"},
+							{Severity: "SEVERITY_LOW"},
+						},
+					},
+				},
+			},
+			expected: map[string]int{
+				"HIGH": 1,
+				"LOW":  1,
+			},
+			wantErr: false,
+		},
+		{
+			name: "InvalidSeverity_UnknownPolicy_Succeeds",
+			report: templates.IACReportTemplate{
+				Response: templates.Responses{
+					IacValidationReport: templates.IACValidationReport{
+						Violations: []templates.Violation{
+							{Severity: "INVALID"},
+						},
+					},
+				},
+			},
+			severityPolicy: severity.Policy{Mode: severity.MODE_UNKNOWN},
+			expected: map[string]int{
+				"UNKNOWN": 1,
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := fetchViolationFromIACReport(test.report)
+			got, err := fetchViolationFromIACReport(test.report, test.severityPolicy)
 
 			if (err != nil) != test.wantErr {
 				t.Errorf("Expected error: %v, got: %v", test.wantErr, err)
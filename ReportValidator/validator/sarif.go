@@ -0,0 +1,299 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+)
+
+// SARIF_VERSION is the only SARIF version that is evaluated.
+const SARIF_VERSION = "2.1.0"
+
+// LEVEL_SEVERITIES maps SARIF levels to the severity of results that carry
+// neither a severity nor a security-severity. Results of level "none" are not
+// counted.
+var LEVEL_SEVERITIES = map[string]string{
+	"error":   severity.HIGH,
+	"warning": severity.MEDIUM,
+	"note":    severity.LOW,
+}
+
+// sarifLog holds the parts of a SARIF log the severity is recovered from.
+// Properties are kept untyped, as other tools use other types for them.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Rules []sarifRule `json:"rules"`
+		} `json:"driver"`
+		Extensions []struct {
+			Rules []sarifRule `json:"rules"`
+		} `json:"extensions"`
+	} `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type sarifRule struct {
+	ID                   string `json:"id"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Rule      *struct {
+		ID            string `json:"id"`
+		Index         *int   `json:"index"`
+		ToolComponent *struct {
+			Index *int `json:"index"`
+		} `json:"toolComponent"`
+	} `json:"rule"`
+	Level        string `json:"level"`
+	Suppressions []struct {
+		Status string `json:"status"`
+	} `json:"suppressions"`
+	BaselineState string                 `json:"baselineState"`
+	Properties    map[string]interface{} `json:"properties"`
+}
+
+// EvaluateSarifReport is EvaluateIACScanReport for a SARIF 2.1.0 log of any
+// tool. See fetchViolationsFromSarifReport for how severities are recovered.
+func EvaluateSarifReport(sarifReport []byte, thresholds map[string]int, operator string, severityPolicy severity.Policy) (bool, error) {
+	severityCounts, err := fetchViolationsFromSarifReport(sarifReport, severityPolicy)
+	if err != nil {
+		return false, fmt.Errorf("fetchViolationsFromSarifReport(): %v", err)
+	}
+
+	failureCriteriaViolations := computeViolationState(severityCounts, thresholds)
+
+	return isBreachingThreshold(operator, failureCriteriaViolations)
+}
+
+// fetchViolationsFromSarifReport counts the results of all runs by severity.
+// Suppressed results and results absent since the baseline are skipped. The
+// severity of a result is, in order of preference, the severity property of
+// the result or its rule, the security-severity property of the result or
+// its rule, or its level. Runs that dropped results to stay within the upload
+// limits are counted from their properties, see droppedSeverityCounts.
+func fetchViolationsFromSarifReport(sarifReport []byte, severityPolicy severity.Policy) (map[string]int, error) {
+	var log sarifLog
+	if err := json.Unmarshal(sarifReport, &log); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	if log.Version != SARIF_VERSION {
+		return nil, fmt.Errorf("unsupported SARIF version: %q", log.Version)
+	}
+
+	severityCounts := make(map[string]int)
+
+	for i, run := range log.Runs {
+		counts, err := droppedSeverityCounts(run, severityPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("runs[%d]: %v", i, err)
+		}
+		if counts != nil {
+			for s, count := range counts {
+				severityCounts[s] += count
+			}
+			continue
+		}
+
+		for j, result := range run.Results {
+			if isSuppressed(result) || result.BaselineState == "absent" {
+				continue
+			}
+
+			s, err := resultSeverity(result, findRule(run, result), severityPolicy)
+			if err != nil {
+				return nil, fmt.Errorf("runs[%d].results[%d]: %v", i, j, err)
+			}
+			if s == "" {
+				continue
+			}
+
+			severityCounts[s]++
+		}
+	}
+
+	return severityCounts, nil
+}
+
+// droppedSeverityCounts returns the severityCounts property of a run that
+// dropped results, as set by the converter's LimitResults, since its results
+// no longer tell how many violations there are. Unlike the results, these
+// counts include suppressed violations. It returns nil for runs without
+// dropped results, and fails for those without severity counts rather than
+// undercounting them.
+func droppedSeverityCounts(run sarifRun, severityPolicy severity.Policy) (map[string]int, error) {
+	dropped, _ := run.Properties["droppedResults"].(float64)
+	if dropped <= 0 {
+		return nil, nil
+	}
+
+	counts, ok := run.Properties["severityCounts"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v results were dropped and the run has no severityCounts to count them from", dropped)
+	}
+
+	severityCounts := make(map[string]int)
+	for s, count := range counts {
+		n, ok := count.(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid severityCounts[%s]: %v", s, count)
+		}
+		if n == 0 {
+			continue
+		}
+
+		normalized, err := severity.Normalize(s, severityPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("severityCounts: %v", err)
+		}
+		severityCounts[normalized] += int(n)
+	}
+
+	return severityCounts, nil
+}
+
+// isSuppressed reports whether a suppression of result is in effect, those
+// without a status count as accepted.
+func isSuppressed(result sarifResult) bool {
+	for _, suppression := range result.Suppressions {
+		if suppression.Status == "" || suppression.Status == "accepted" {
+			return true
+		}
+	}
+	return false
+}
+
+// findRule looks the rule of result up by index, falling back to its ID, in
+// the driver or in the extension that result.rule refers to.
+func findRule(run sarifRun, result sarifResult) *sarifRule {
+	rules := run.Tool.Driver.Rules
+	ruleIndex, ruleID := result.RuleIndex, result.RuleID
+
+	if result.Rule != nil {
+		if component := result.Rule.ToolComponent; component != nil && component.Index != nil {
+			if *component.Index < 0 || *component.Index >= len(run.Tool.Extensions) {
+				return nil
+			}
+			rules = run.Tool.Extensions[*component.Index].Rules
+		}
+		ruleIndex = result.Rule.Index
+		if result.Rule.ID != "" {
+			ruleID = result.Rule.ID
+		}
+	}
+
+	if ruleIndex != nil && *ruleIndex >= 0 && *ruleIndex < len(rules) {
+		return &rules[*ruleIndex]
+	}
+
+	for i := range rules {
+		if ruleID != "" && rules[i].ID == ruleID {
+			return &rules[i]
+		}
+	}
+
+	return nil
+}
+
+// resultSeverity returns "" for results that should not be counted.
+func resultSeverity(result sarifResult, rule *sarifRule, severityPolicy severity.Policy) (string, error) {
+	ruleProperties := map[string]interface{}{}
+	if rule != nil && rule.Properties != nil {
+		ruleProperties = rule.Properties
+	}
+
+	for _, properties := range []map[string]interface{}{result.Properties, ruleProperties} {
+		if s, ok := properties["severity"].(string); ok && s != "" {
+			return severity.Normalize(s, severityPolicy)
+		}
+	}
+
+	for _, properties := range []map[string]interface{}{result.Properties, ruleProperties} {
+		if score, ok := properties["security-severity"]; ok {
+			return fromSecuritySeverity(score)
+		}
+	}
+
+	level := result.Level
+	if level == "" && rule != nil {
+		level = rule.DefaultConfiguration.Level
+	}
+	if level == "" {
+		// The SARIF default.
+		level = "warning"
+	}
+
+	if level == "none" {
+		return "", nil
+	}
+
+	s, ok := LEVEL_SEVERITIES[level]
+	if !ok {
+		return "", fmt.Errorf("invalid level: %s", level)
+	}
+	return s, nil
+}
+
+// fromSecuritySeverity maps a security-severity score, a string by
+// convention but a number in some tools, to a severity the way GitHub ranks
+// alerts: above 9.0 is critical, from 7.0 high, from 4.0 medium, low below.
+// A score of 0 is not counted.
+func fromSecuritySeverity(score interface{}) (string, error) {
+	var value float64
+	switch score := score.(type) {
+	case float64:
+		value = score
+	case string:
+		var err error
+		value, err = strconv.ParseFloat(strings.TrimSpace(score), 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid security-severity: %q", score)
+		}
+	default:
+		return "", fmt.Errorf("invalid security-severity: %v", score)
+	}
+
+	switch {
+	case value > 9.0:
+		return severity.CRITICAL, nil
+	case value >= 7.0:
+		return severity.HIGH, nil
+	case value >= 4.0:
+		return severity.MEDIUM, nil
+	case value > 0:
+		return severity.LOW, nil
+	default:
+		return "", nil
+	}
+}
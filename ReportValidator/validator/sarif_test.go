@@ -0,0 +1,328 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package validator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/converter"
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestEvaluateSarifReport(t *testing.T) {
+	sarifReport := `{"version": "2.1.0", "runs": [{
+		"tool": {"driver": {"name": "scanner", "rules": [{"id": "R1", "properties": {"severity": "CRITICAL"}}]}},
+		"results": [{"ruleId": "R1", "ruleIndex": 0, "level": "error"}]
+	}]}`
+
+	tests := []struct {
+		name                 string
+		threshold            map[string]int
+		operator             string
+		isBreachingThreshold bool
+	}{
+		{
+			name:                 "SeverityExceededThreshold",
+			threshold:            map[string]int{"CRITICAL": 1},
+			operator:             "OR",
+			isBreachingThreshold: true,
+		},
+		{
+			name:                 "SeverityBelowThreshold",
+			threshold:            map[string]int{"HIGH": 1},
+			operator:             "OR",
+			isBreachingThreshold: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := EvaluateSarifReport([]byte(sarifReport), test.threshold, test.operator, severity.Policy{})
+			if err != nil {
+				t.Fatalf("EvaluateSarifReport() failed: %v", err)
+			}
+
+			if got != test.isBreachingThreshold {
+				t.Errorf("EvaluateSarifReport() = %v, want %v", got, test.isBreachingThreshold)
+			}
+		})
+	}
+}
+
+func TestFetchViolationsFromSarifReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		sarifReport    string
+		severityPolicy severity.Policy
+		expected       map[string]int
+		wantErr        bool
+	}{
+		{
+			name: "RuleSeverityByIndexAndID_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {"rules": [
+					{"id": "R1", "properties": {"severity": "HIGH"}},
+					{"id": "R2", "properties": {"severity": "severity_low"}}
+				]}},
+				"results": [{"ruleIndex": 0, "level": "note"}, {"ruleId": "R2"}]
+			}]}`,
+			expected: map[string]int{
+				"HIGH": 1,
+				"LOW":  1,
+			},
+		},
+		{
+			name: "RuleOfExtension_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {
+					"driver": {"rules": [{"id": "R1", "properties": {"severity": "LOW"}}]},
+					"extensions": [
+						{"name": "posture1", "rules": [{"id": "R2", "properties": {"severity": "CRITICAL"}}]},
+						{"name": "posture2", "rules": [{"id": "R3", "properties": {"severity": "MEDIUM"}}]}
+					]
+				},
+				"results": [
+					{"ruleId": "R2", "ruleIndex": -1, "rule": {"id": "R2", "index": 0, "toolComponent": {"index": 0}}},
+					{"ruleId": "R3", "ruleIndex": -1, "rule": {"id": "R3", "toolComponent": {"index": 1}}},
+					{"ruleId": "R1", "ruleIndex": 0}
+				]
+			}]}`,
+			expected: map[string]int{
+				"CRITICAL": 1,
+				"MEDIUM":   1,
+				"LOW":      1,
+			},
+		},
+		{
+			name: "ResultSeverityOverridesRule_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {"rules": [{"id": "R1", "properties": {"severity": "HIGH"}}]}},
+				"results": [{"ruleId": "R1", "properties": {"severity": "MEDIUM"}}]
+			}]}`,
+			expected: map[string]int{
+				"MEDIUM": 1,
+			},
+		},
+		{
+			name: "SecuritySeverity_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {"rules": [
+					{"id": "R1", "properties": {"security-severity": "9.5"}},
+					{"id": "R2", "properties": {"security-severity": "7.0"}},
+					{"id": "R3", "properties": {"security-severity": 4}},
+					{"id": "R4", "properties": {"security-severity": "2.0"}},
+					{"id": "R5", "properties": {"security-severity": "0.0"}}
+				]}},
+				"results": [{"ruleId": "R1"}, {"ruleId": "R2"}, {"ruleId": "R3"}, {"ruleId": "R4"}, {"ruleId": "R5"}]
+			}]}`,
+			expected: map[string]int{
+				"CRITICAL": 1,
+				"HIGH":     1,
+				"MEDIUM":   1,
+				"LOW":      1,
+			},
+		},
+		{
+			name: "Level_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [
+				{
+					"tool": {"driver": {"rules": [{"id": "R1", "defaultConfiguration": {"level": "note"}}]}},
+					"results": [{"ruleId": "R1"}, {"ruleId": "R1", "level": "error"}, {"ruleId": "R1", "level": "none"}]
+				},
+				{
+					"tool": {"driver": {}},
+					"results": [{"ruleId": "other"}]
+				}
+			]}`,
+			expected: map[string]int{
+				"HIGH":   1,
+				"MEDIUM": 1,
+				"LOW":    1,
+			},
+		},
+		{
+			name: "SuppressedAndAbsentResultsSkipped_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {}},
+				"results": [
+					{"level": "error", "suppressions": [{"kind": "external"}]},
+					{"level": "error", "suppressions": [{"kind": "external", "status": "rejected"}]},
+					{"level": "error", "baselineState": "absent"},
+					{"level": "error", "baselineState": "new"}
+				]
+			}]}`,
+			expected: map[string]int{
+				"HIGH": 2,
+			},
+		},
+		{
+			name: "DroppedResultsCountedFromProperties_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [
+				{
+					"tool": {"driver": {}},
+					"results": [{"level": "error"}],
+					"properties": {"severityCounts": {"HIGH": 1, "LOW": 2, "MEDIUM": 0}, "totalViolations": 3, "droppedResults": 2}
+				},
+				{
+					"tool": {"driver": {}},
+					"results": [{"level": "note"}],
+					"properties": {"severityCounts": {"CRITICAL": 5}, "totalViolations": 5}
+				}
+			]}`,
+			expected: map[string]int{
+				"HIGH": 1,
+				"LOW":  3,
+			},
+		},
+		{
+			name: "DroppedResultsWithoutSeverityCounts_Failure",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {}},
+				"results": [{"level": "error"}],
+				"properties": {"droppedResults": 2}
+			}]}`,
+			wantErr: true,
+		},
+		{
+			name: "InvalidSeverity_UnknownPolicy_Succeeds",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {}},
+				"results": [{"properties": {"severity": "INFO"}}]
+			}]}`,
+			severityPolicy: severity.Policy{Mode: severity.MODE_UNKNOWN},
+			expected: map[string]int{
+				"UNKNOWN": 1,
+			},
+		},
+		{
+			name: "InvalidSeverity_Failure",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {}},
+				"results": [{"properties": {"severity": "INFO"}}]
+			}]}`,
+			wantErr: true,
+		},
+		{
+			name: "InvalidSecuritySeverity_Failure",
+			sarifReport: `{"version": "2.1.0", "runs": [{
+				"tool": {"driver": {}},
+				"results": [{"properties": {"security-severity": "high"}}]
+			}]}`,
+			wantErr: true,
+		},
+		{
+			name:        "UnsupportedVersion_Failure",
+			sarifReport: `{"version": "2.0.0", "runs": []}`,
+			wantErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := fetchViolationsFromSarifReport([]byte(test.sarifReport), test.severityPolicy)
+
+			if (err != nil) != test.wantErr {
+				t.Errorf("Expected error: %v, got: %v", test.wantErr, err)
+			}
+
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("Unexpected result: diff (+got -want):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestFetchViolationsFromSarifReport_SameAsIACReport checks that the SARIF
+// output of the converter is counted like the report it was converted from,
+// also when the violations of a policy differ in severity.
+func TestFetchViolationsFromSarifReport_SameAsIACReport(t *testing.T) {
+	iacReport := templates.IACReportTemplate{
+		Done: true,
+		Response: templates.Responses{
+			IacValidationReport: templates.IACValidationReport{
+				Violations: []templates.Violation{
+					{PolicyID: "P1", AssetID: "a1", Severity: "HIGH"},
+					{PolicyID: "P1", AssetID: "a2", Severity: "LOW"},
+					{PolicyID: "P1", AssetID: "a3", Severity: "LOW"},
+					{PolicyID: "P2", AssetID: "a1", Severity: "MEDIUM"},
+				},
+			},
+		},
+	}
+
+	want, err := fetchViolationFromIACReport(iacReport, severity.Policy{})
+	if err != nil {
+		t.Fatalf("fetchViolationFromIACReport() failed: %v", err)
+	}
+
+	sarifReport, err := converter.FromIACScanReport(iacReport.Response, converter.Options{})
+	if err != nil {
+		t.Fatalf("converter.FromIACScanReport() failed: %v", err)
+	}
+	sarifJSON, err := json.Marshal(sarifReport)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+
+	got, err := fetchViolationsFromSarifReport(sarifJSON, severity.Policy{})
+	if err != nil {
+		t.Fatalf("fetchViolationsFromSarifReport() failed: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Counts of the SARIF and IaC report differ (-iac, +sarif):\n%s", diff)
+	}
+}
+
+// TestEvaluateSarifReport_LimitedResults checks that the gate still counts the
+// violations whose results LimitResults dropped.
+func TestEvaluateSarifReport_LimitedResults(t *testing.T) {
+	response := templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				{PolicyID: "P1", AssetID: "a1", Severity: "HIGH"},
+				{PolicyID: "P2", AssetID: "a1", Severity: "LOW"},
+				{PolicyID: "P3", AssetID: "a1", Severity: "LOW"},
+			},
+		},
+	}
+
+	sarifReport, err := converter.FromIACScanReport(response, converter.Options{})
+	if err != nil {
+		t.Fatalf("converter.FromIACScanReport() failed: %v", err)
+	}
+	sarifReport = converter.LimitResults(sarifReport, 0, 1)
+	if got := len(sarifReport.Runs[0].Results); got != 1 {
+		t.Fatalf("converter.LimitResults() kept %d results, want 1", got)
+	}
+	sarifJSON, err := json.Marshal(sarifReport)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+
+	got, err := EvaluateSarifReport(sarifJSON, map[string]int{"LOW": 1}, "OR", severity.Policy{})
+	if err != nil {
+		t.Fatalf("EvaluateSarifReport() failed: %v", err)
+	}
+	if !got {
+		t.Errorf("EvaluateSarifReport() = false, want the dropped LOW results to breach the threshold")
+	}
+}
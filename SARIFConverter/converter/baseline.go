@@ -0,0 +1,151 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/gcp-scc-iac-validation-utils/loader"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// Baseline states of the results.
+const (
+	BASELINE_STATE_NEW       = "new"
+	BASELINE_STATE_UNCHANGED = "unchanged"
+	BASELINE_STATE_UPDATED   = "updated"
+	BASELINE_STATE_ABSENT    = "absent"
+)
+
+// ReadSarifReport reads a SARIF report, optionally gzip compressed. Rules of
+// posture extensions are moved back into the driver, see PostureExtensions.
+func ReadSarifReport(filePath string) (templates.SarifOutput, error) {
+	data, err := loader.ReadInput(filePath)
+	if err != nil {
+		return templates.SarifOutput{}, fmt.Errorf("loader.ReadInput: %v", err)
+	}
+
+	var sarifReport templates.SarifOutput
+	if err := json.Unmarshal(data, &sarifReport); err != nil {
+		return templates.SarifOutput{}, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	for i, run := range sarifReport.Runs {
+		// Results are always written, an empty list being a run without
+		// findings, e.g. an empty part of Split.
+		if run.Results == nil {
+			run.Results = []templates.Result{}
+		}
+		sarifReport.Runs[i] = flattenExtensions(run)
+	}
+
+	return sarifReport, nil
+}
+
+// resultFingerprint returns the fingerprint set by Fingerprint, or "" for
+// results of other tools.
+func resultFingerprint(result templates.Result) string {
+	if fingerprint := result.Fingerprints[FINGERPRINT_KEY]; fingerprint != "" {
+		return fingerprint
+	}
+	return result.PartialFingerprints[FINGERPRINT_KEY]
+}
+
+// applyBaseline sets the baselineState of results by matching their
+// fingerprints with the results of baseline. A matched result is unchanged
+// when its level, message and locations are the same, and updated otherwise.
+// Baseline results without a match are added as absent, together with their
+// rules when the current report has none for them. Only the baseline runs of
+// category are used, and results that were already absent in baseline are
+// left out, so that a violation that comes back is new again.
+func applyBaseline(rules []templates.Rule, results []templates.Result, baseline templates.SarifOutput, category string) ([]templates.Rule, []templates.Result) {
+	baselineResults := make(map[string][]templates.Result)
+	baselineRules := make(map[string]templates.Rule)
+	for _, run := range baseline.Runs {
+		if runCategory(run) != category {
+			continue
+		}
+		for _, rule := range run.Tool.Driver.Rules {
+			baselineRules[rule.ID] = rule
+		}
+		for _, result := range run.Results {
+			if result.BaselineState == BASELINE_STATE_ABSENT {
+				continue
+			}
+			if fingerprint := resultFingerprint(result); fingerprint != "" {
+				baselineResults[fingerprint] = append(baselineResults[fingerprint], result)
+			}
+		}
+	}
+
+	for i := range results {
+		fingerprint := resultFingerprint(results[i])
+		matches := baselineResults[fingerprint]
+		if len(matches) == 0 {
+			results[i].BaselineState = BASELINE_STATE_NEW
+			continue
+		}
+
+		previous := matches[0]
+		baselineResults[fingerprint] = matches[1:]
+
+		if results[i].Level == previous.Level && results[i].Message == previous.Message && reflect.DeepEqual(results[i].Locations, previous.Locations) {
+			results[i].BaselineState = BASELINE_STATE_UNCHANGED
+		} else {
+			results[i].BaselineState = BASELINE_STATE_UPDATED
+		}
+	}
+
+	ruleIDs := make(map[string]bool)
+	for _, rule := range rules {
+		ruleIDs[rule.ID] = true
+	}
+
+	absent := []templates.Result{}
+	for _, matches := range baselineResults {
+		for _, result := range matches {
+			result.BaselineState = BASELINE_STATE_ABSENT
+			absent = append(absent, result)
+
+			if rule, ok := baselineRules[result.RuleID]; ok && !ruleIDs[result.RuleID] {
+				ruleIDs[result.RuleID] = true
+				rules = append(rules, rule)
+			}
+		}
+	}
+	if len(absent) == 0 {
+		return rules, results
+	}
+
+	// Absent results come from a map, sort them by fingerprint first so that
+	// the output stays deterministic.
+	sortByFingerprint(absent)
+	results = append(results, absent...)
+	sortRules(rules)
+	sortResults(results)
+
+	return rules, results
+}
+
+func sortByFingerprint(results []templates.Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return resultFingerprint(results[i]) < resultFingerprint(results[j])
+	})
+}
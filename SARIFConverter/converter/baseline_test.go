@@ -0,0 +1,133 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestBaselineState(t *testing.T) {
+	previous := templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				{PolicyID: "P1", AssetID: "unchanged", Severity: "HIGH"},
+				{PolicyID: "P1", AssetID: "updated", Severity: "HIGH"},
+				{PolicyID: "P2", AssetID: "fixed", Severity: "LOW"},
+			},
+		},
+	}
+	current := templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				{PolicyID: "P1", AssetID: "unchanged", Severity: "HIGH"},
+				{PolicyID: "P1", AssetID: "updated", Severity: "CRITICAL", NextSteps: "New next steps"},
+				{PolicyID: "P3", AssetID: "introduced", Severity: "MEDIUM"},
+			},
+		},
+	}
+
+	baseline, err := FromIACScanReport(previous, Options{})
+	if err != nil {
+		t.Fatalf("FromIACScanReport(previous) failed: %v", err)
+	}
+
+	// Round trip the baseline through a file like --baseline_sarif does.
+	baselinePath := filepath.Join(t.TempDir(), "baseline.sarif")
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(baselinePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err = ReadSarifReport(baselinePath)
+	if err != nil {
+		t.Fatalf("ReadSarifReport() failed: %v", err)
+	}
+
+	output, err := FromIACScanReport(current, Options{Baseline: &baseline})
+	if err != nil {
+		t.Fatalf("FromIACScanReport(current) failed: %v", err)
+	}
+	run := output.Runs[0]
+
+	type state struct {
+		RuleID, AssetID, BaselineState string
+	}
+	got := []state{}
+	for _, result := range run.Results {
+		got = append(got, state{result.RuleID, result.Properties.AssetID, result.BaselineState})
+		if rule := run.Tool.Driver.Rules[result.RuleIndex]; rule.ID != result.RuleID {
+			t.Errorf("result %s/%s has ruleIndex %d pointing at rule %s", result.RuleID, result.Properties.AssetID, result.RuleIndex, rule.ID)
+		}
+	}
+
+	want := []state{
+		{"P1", "unchanged", "unchanged"},
+		{"P1", "updated", "updated"},
+		{"P2", "fixed", "absent"},
+		{"P3", "introduced", "new"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected baseline states (-want, +got): %v", diff)
+	}
+}
+
+// TestBaselineState_Chained checks that each report only uses the previous
+// one as baseline, so a fixed violation is absent once and new when it comes
+// back.
+func TestBaselineState_Chained(t *testing.T) {
+	p1 := templates.Violation{PolicyID: "P1", AssetID: "a1", Severity: "HIGH"}
+	p2 := templates.Violation{PolicyID: "P2", AssetID: "a1", Severity: "LOW"}
+	reports := [][]templates.Violation{{p1, p2}, {p1}, {p1}, {p1, p2}}
+
+	type state struct {
+		RuleID, BaselineState string
+	}
+	want := [][]state{
+		{{"P1", ""}, {"P2", ""}},
+		{{"P1", "unchanged"}, {"P2", "absent"}},
+		{{"P1", "unchanged"}},
+		{{"P1", "unchanged"}, {"P2", "new"}},
+	}
+
+	var baseline *templates.SarifOutput
+	for i, violations := range reports {
+		response := templates.Responses{IacValidationReport: templates.IACValidationReport{Violations: violations}}
+		output, err := FromIACScanReport(response, Options{Baseline: baseline})
+		if err != nil {
+			t.Fatalf("FromIACScanReport(r%d) failed: %v", i+1, err)
+		}
+
+		got := []state{}
+		for _, result := range output.Runs[0].Results {
+			got = append(got, state{result.RuleID, result.BaselineState})
+		}
+		if diff := cmp.Diff(want[i], got); diff != "" {
+			t.Errorf("Unexpected baseline states of r%d (-want, +got): %v", i+1, diff)
+		}
+
+		baseline = &output
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"path"
+	"sort"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// RULE_INDEX_NONE is the ruleIndex of results whose rule is not a rule of the
+// driver but of an extension, see result.rule.
+const RULE_INDEX_NONE = -1
+
+type postureRevision struct {
+	posture  string
+	revision string
+}
+
+// PostureExtensions moves the rules of every posture revision from the driver
+// into a tool extension of its own, named after the posture and versioned by
+// the revision ID, and points the results at them with result.rule. Rules
+// without posture stay with the driver.
+//
+// The other functions of this package expect the rules in the driver, which
+// ReadSarifReport takes care of, so this is meant to be the last step before
+// writing the report.
+func PostureExtensions(sarifReport templates.SarifOutput) templates.SarifOutput {
+	runs := []templates.Run{}
+	for _, run := range sarifReport.Runs {
+		runs = append(runs, postureExtensions(run))
+	}
+	sarifReport.Runs = runs
+	return sarifReport
+}
+
+func postureExtensions(run templates.Run) templates.Run {
+	driverRules := []templates.Rule{}
+	rulesByRevision := make(map[postureRevision][]templates.Rule)
+	for _, rule := range run.Tool.Driver.Rules {
+		if rule.Properties.Posture == "" {
+			driverRules = append(driverRules, rule)
+			continue
+		}
+		revision := postureRevision{posture: rule.Properties.Posture, revision: rule.Properties.PostureRevisionID}
+		rulesByRevision[revision] = append(rulesByRevision[revision], rule)
+	}
+
+	if len(rulesByRevision) == 0 {
+		return run
+	}
+
+	revisions := []postureRevision{}
+	for revision := range rulesByRevision {
+		revisions = append(revisions, revision)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		if revisions[i].posture != revisions[j].posture {
+			return revisions[i].posture < revisions[j].posture
+		}
+		return revisions[i].revision < revisions[j].revision
+	})
+
+	type ruleReference struct {
+		extension, index int
+	}
+	references := make(map[string]ruleReference)
+	extensions := []templates.ToolComponent{}
+	for i, revision := range revisions {
+		rules := rulesByRevision[revision]
+		policySets := make(map[string]bool)
+		for j, rule := range rules {
+			references[rule.ID] = ruleReference{extension: i, index: j}
+			if rule.Properties.PolicySet != "" {
+				policySets[rule.Properties.PolicySet] = true
+			}
+		}
+
+		extension := templates.ToolComponent{
+			Name:     path.Base(revision.posture),
+			FullName: revision.posture,
+			Version:  revision.revision,
+			Rules:    rules,
+		}
+		if len(policySets) > 0 {
+			extension.Properties = &templates.ToolComponentProperties{PolicySets: sortedKeys(policySets)}
+		}
+		extensions = append(extensions, extension)
+	}
+
+	results := append([]templates.Result{}, run.Results...)
+	setRuleIndexes(driverRules, results)
+	for i, result := range results {
+		reference, ok := references[result.RuleID]
+		if !ok {
+			continue
+		}
+		ruleIndex, extensionIndex := reference.index, reference.extension
+		results[i].RuleIndex = RULE_INDEX_NONE
+		results[i].Rule = &templates.DescriptorReference{
+			ID:    result.RuleID,
+			Index: &ruleIndex,
+			ToolComponent: templates.ToolComponentReference{
+				Name:  extensions[extensionIndex].Name,
+				Index: &extensionIndex,
+			},
+		}
+	}
+
+	run.Tool.Driver.Rules = driverRules
+	run.Tool.Extensions = extensions
+	run.Results = results
+	return run
+}
+
+// flattenExtensions reverts PostureExtensions, moving the rules of the
+// extensions back into the driver.
+func flattenExtensions(run templates.Run) templates.Run {
+	if len(run.Tool.Extensions) == 0 {
+		return run
+	}
+
+	ruleIDs := make(map[string]bool)
+	rules := []templates.Rule{}
+	for _, component := range append([]templates.ToolComponent{{Rules: run.Tool.Driver.Rules}}, run.Tool.Extensions...) {
+		for _, rule := range component.Rules {
+			if !ruleIDs[rule.ID] {
+				ruleIDs[rule.ID] = true
+				rules = append(rules, rule)
+			}
+		}
+	}
+	sortRules(rules)
+
+	results := append([]templates.Result{}, run.Results...)
+	for i := range results {
+		results[i].Rule = nil
+	}
+	setRuleIndexes(rules, results)
+
+	run.Tool.Driver.Rules = rules
+	run.Tool.Extensions = nil
+	run.Results = results
+	return run
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
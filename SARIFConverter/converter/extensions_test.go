@@ -0,0 +1,148 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func postureViolation(policyID, posture, revision, policySet string) templates.Violation {
+	return templates.Violation{
+		PolicyID: policyID,
+		AssetID:  "asset",
+		Severity: "HIGH",
+		ViolatedPosture: templates.PostureDetails{
+			Posture:           posture,
+			PostureRevisionID: revision,
+			PolicySet:         policySet,
+		},
+	}
+}
+
+func TestPostureExtensions(t *testing.T) {
+	const (
+		posture1 = "organizations/1/locations/global/postures/posture1"
+		posture2 = "organizations/1/locations/global/postures/posture2"
+	)
+
+	sarifReport, err := FromIACScanReport(templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				postureViolation("P1", posture2, "r1", "set1"),
+				postureViolation("P2", posture1, "r2", "set2"),
+				postureViolation("P3", posture1, "r2", "set1"),
+				postureViolation("P4", "", "", ""),
+			},
+		},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("FromIACScanReport() failed: %v", err)
+	}
+
+	got := PostureExtensions(sarifReport)
+	run := got.Runs[0]
+
+	type component struct {
+		Name       string
+		FullName   string
+		Version    string
+		RuleIDs    []string
+		PolicySets []string
+	}
+	components := []component{}
+	for _, extension := range run.Tool.Extensions {
+		ruleIDs := []string{}
+		for _, rule := range extension.Rules {
+			ruleIDs = append(ruleIDs, rule.ID)
+		}
+		c := component{Name: extension.Name, FullName: extension.FullName, Version: extension.Version, RuleIDs: ruleIDs}
+		if extension.Properties != nil {
+			c.PolicySets = extension.Properties.PolicySets
+		}
+		components = append(components, c)
+	}
+
+	wantComponents := []component{
+		{Name: "posture1", FullName: posture1, Version: "r2", RuleIDs: []string{"P2", "P3"}, PolicySets: []string{"set1", "set2"}},
+		{Name: "posture2", FullName: posture2, Version: "r1", RuleIDs: []string{"P1"}, PolicySets: []string{"set1"}},
+	}
+	if diff := cmp.Diff(wantComponents, components); diff != "" {
+		t.Errorf("PostureExtensions() unexpected extensions (-want, +got): %v", diff)
+	}
+
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != "P4" {
+		t.Errorf("PostureExtensions() left driver rules %v, want P4", run.Tool.Driver.Rules)
+	}
+
+	zero, one := 0, 1
+	wantReferences := []*templates.DescriptorReference{
+		{ID: "P1", Index: &zero, ToolComponent: templates.ToolComponentReference{Name: "posture2", Index: &one}},
+		{ID: "P2", Index: &zero, ToolComponent: templates.ToolComponentReference{Name: "posture1", Index: &zero}},
+		{ID: "P3", Index: &one, ToolComponent: templates.ToolComponentReference{Name: "posture1", Index: &zero}},
+		nil,
+	}
+	wantIndexes := []int{RULE_INDEX_NONE, RULE_INDEX_NONE, RULE_INDEX_NONE, 0}
+	references := []*templates.DescriptorReference{}
+	indexes := []int{}
+	for _, result := range run.Results {
+		references = append(references, result.Rule)
+		indexes = append(indexes, result.RuleIndex)
+	}
+	if diff := cmp.Diff(wantReferences, references); diff != "" {
+		t.Errorf("PostureExtensions() unexpected rule references (-want, +got): %v", diff)
+	}
+	if diff := cmp.Diff(wantIndexes, indexes); diff != "" {
+		t.Errorf("PostureExtensions() unexpected rule indexes (-want, +got): %v", diff)
+	}
+
+	// ReadSarifReport moves the rules back into the driver.
+	sarifJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	filePath := filepath.Join(t.TempDir(), "report.sarif")
+	if err := os.WriteFile(filePath, sarifJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSarifReport(filePath)
+	if err != nil {
+		t.Fatalf("ReadSarifReport() failed: %v", err)
+	}
+	if diff := cmp.Diff(sarifReport, read, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("ReadSarifReport() did not revert PostureExtensions() (-want, +got): %v", diff)
+	}
+}
+
+func TestPostureExtensions_WithoutPostures(t *testing.T) {
+	sarifReport := templates.SarifOutput{
+		Version: SARIF_VERSION,
+		Runs:    []templates.Run{IACValidSarifOutput.Runs[0]},
+	}
+	sarifReport.Runs[0].Tool.Driver.Rules = []templates.Rule{{ID: "P1"}}
+
+	if diff := cmp.Diff(sarifReport, PostureExtensions(sarifReport)); diff != "" {
+		t.Errorf("PostureExtensions() changed report without postures (-want, +got): %v", diff)
+	}
+}
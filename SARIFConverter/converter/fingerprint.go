@@ -0,0 +1,60 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// FINGERPRINT_KEY is the key of the fingerprint in the fingerprints and
+// partialFingerprints of the results. The version suffix changes whenever
+// the way the fingerprint is computed changes.
+const FINGERPRINT_KEY = "sccIacViolation/v1"
+
+// Fingerprint identifies a violation across scans. It is the lowercase hex
+// SHA-256 of the policy ID, asset ID and posture name joined by "|", e.g.
+// sha256("P1|//storage.googleapis.com/buckets/b|my-posture"). Severity,
+// posture revision and next steps are left out on purpose so that they can
+// change without the violation being reported as new.
+func Fingerprint(violation templates.Violation) string {
+	key := strings.Join([]string{violation.PolicyID, violation.AssetID, violation.ViolatedPosture.Posture}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RevisionFingerprint is Fingerprint with the posture revision ID appended,
+// e.g. sha256("P1|//storage.googleapis.com/buckets/b|my-posture|r1"). It is
+// used with RULE_KEY_POLICY_REVISION, where every revision of a policy is a
+// rule of its own whose results must not share fingerprints.
+func RevisionFingerprint(violation templates.Violation) string {
+	key := strings.Join([]string{violation.PolicyID, violation.AssetID, violation.ViolatedPosture.Posture, violation.ViolatedPosture.PostureRevisionID}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// violationFingerprint returns the fingerprint matching the rule of violation,
+// see ruleID.
+func violationFingerprint(violation templates.Violation, key string) string {
+	if key == RULE_KEY_POLICY_REVISION && violation.ViolatedPosture.PostureRevisionID != "" {
+		return RevisionFingerprint(violation)
+	}
+	return Fingerprint(violation)
+}
@@ -0,0 +1,77 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestFingerprint(t *testing.T) {
+	violation := templates.Violation{
+		PolicyID:        "P1",
+		AssetID:         "Asset 1",
+		Severity:        "HIGH",
+		ViolatedPosture: templates.PostureDetails{Posture: "Posture 1", PostureRevisionID: "Rev 1"},
+	}
+
+	// sha256("P1|Asset 1|Posture 1"), other tools rely on this exact value.
+	want := "09763277d41f0d6403b168732bc7b2469e4df8b991e0af9f2ca8a8cb75530672"
+	if got := Fingerprint(violation); got != want {
+		t.Errorf("Fingerprint() = %s, want %s", got, want)
+	}
+
+	changed := violation
+	changed.Severity = "LOW"
+	changed.NextSteps = "Next steps"
+	changed.ViolatedPosture.PostureRevisionID = "Rev 2"
+	if got := Fingerprint(changed); got != want {
+		t.Errorf("Fingerprint() = %s after changing severity, next steps and revision, want %s", got, want)
+	}
+
+	changed.ViolatedPosture.Posture = "Posture 2"
+	if got := Fingerprint(changed); got == want {
+		t.Errorf("Fingerprint() = %s for a different posture, want a different value", got)
+	}
+}
+
+func TestRevisionFingerprint(t *testing.T) {
+	violation := templates.Violation{
+		PolicyID:        "P1",
+		AssetID:         "Asset 1",
+		ViolatedPosture: templates.PostureDetails{Posture: "Posture 1", PostureRevisionID: "Rev 1"},
+	}
+
+	// sha256("P1|Asset 1|Posture 1|Rev 1")
+	want := "35ce2a05a036407165ce3347bdf5cace0fe320f223f16a63049789426729c8a9"
+	if got := RevisionFingerprint(violation); got != want {
+		t.Errorf("RevisionFingerprint() = %s, want %s", got, want)
+	}
+
+	if got := violationFingerprint(violation, RULE_KEY_POLICY_REVISION); got != want {
+		t.Errorf("violationFingerprint(RULE_KEY_POLICY_REVISION) = %s, want %s", got, want)
+	}
+	if got, want := violationFingerprint(violation, RULE_KEY_POLICY), Fingerprint(violation); got != want {
+		t.Errorf("violationFingerprint(RULE_KEY_POLICY) = %s, want %s", got, want)
+	}
+
+	violation.ViolatedPosture.PostureRevisionID = ""
+	if got, want := violationFingerprint(violation, RULE_KEY_POLICY_REVISION), Fingerprint(violation); got != want {
+		t.Errorf("violationFingerprint() without revision = %s, want %s", got, want)
+	}
+}
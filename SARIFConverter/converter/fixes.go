@@ -0,0 +1,171 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/terraform"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// CMEK_KEY_PLACEHOLDER is inserted where a fix needs a Cloud KMS key, which
+// can't be derived from the report.
+const CMEK_KEY_PLACEHOLDER = `"projects/PROJECT_ID/locations/LOCATION/keyRings/KEY_RING/cryptoKeys/KEY"`
+
+// fix changes the resource blocks of one Terraform resource type so that they
+// satisfy a constraint.
+type fix struct {
+	resourceType string
+	description  string
+	changes      func(sources *terraform.Index, location terraform.Location) ([]terraform.Change, bool)
+}
+
+var (
+	uniformBucketLevelAccessFix = fix{
+		resourceType: "google_storage_bucket",
+		description:  "Enable uniform bucket-level access.",
+		changes:      setAttribute("uniform_bucket_level_access", "true"),
+	}
+	publicAccessPreventionFix = fix{
+		resourceType: "google_storage_bucket",
+		description:  "Enforce public access prevention.",
+		changes:      setAttribute("public_access_prevention", `"enforced"`),
+	}
+	bucketCMEKFix = fix{
+		resourceType: "google_storage_bucket",
+		description:  "Encrypt the bucket with a customer-managed key, replace the placeholder with your Cloud KMS key.",
+		changes:      addBlock("encryption", "default_kms_key_name = "+CMEK_KEY_PLACEHOLDER),
+	}
+	instanceExternalIPFixes = []fix{
+		{
+			resourceType: "google_compute_instance",
+			description:  "Remove the external IP address of the instance.",
+			changes:      removeBlocks("network_interface.access_config"),
+		},
+		{
+			resourceType: "google_compute_instance_template",
+			description:  "Remove the external IP address of the instances.",
+			changes:      removeBlocks("network_interface.access_config"),
+		},
+	}
+	shieldedVMFix = fix{
+		resourceType: "google_compute_instance",
+		description:  "Enable Shielded VM.",
+		changes:      addBlock("shielded_instance_config", "enable_secure_boot = true", "enable_vtpm = true", "enable_integrity_monitoring = true"),
+	}
+	sqlPublicIPFix = fix{
+		resourceType: "google_sql_database_instance",
+		description:  "Remove the public IP address of the instance.",
+		changes:      setAttribute("settings.ip_configuration.ipv4_enabled", "false"),
+	}
+	sqlCMEKFix = fix{
+		resourceType: "google_sql_database_instance",
+		description:  "Encrypt the instance with a customer-managed key, replace the placeholder with your Cloud KMS key.",
+		changes:      setAttribute("encryption_key_name", CMEK_KEY_PLACEHOLDER),
+	}
+	datasetCMEKFix = fix{
+		resourceType: "google_bigquery_dataset",
+		description:  "Encrypt the dataset with a customer-managed key, replace the placeholder with your Cloud KMS key.",
+		changes:      addBlock("default_encryption_configuration", "kms_key_name = "+CMEK_KEY_PLACEHOLDER),
+	}
+)
+
+// fixCatalogue is the catalogue of fixes, keyed by the name of the canned
+// organization policy constraint or Security Health Analytics detector.
+var fixCatalogue = map[string][]fix{
+	"storage.uniformBucketLevelAccess": {uniformBucketLevelAccessFix},
+	"BUCKET_POLICY_ONLY_DISABLED":      {uniformBucketLevelAccessFix},
+	"storage.publicAccessPrevention":   {publicAccessPreventionFix},
+	"compute.vmExternalIpAccess":       instanceExternalIPFixes,
+	"PUBLIC_IP_ADDRESS":                instanceExternalIPFixes,
+	"compute.requireShieldedVm":        {shieldedVMFix},
+	"SHIELDED_VM_DISABLED":             {shieldedVMFix},
+	"sql.restrictPublicIp":             {sqlPublicIPFix},
+	"SQL_PUBLIC_IP":                    {sqlPublicIPFix},
+	"gcp.restrictNonCmekServices":      {bucketCMEKFix, sqlCMEKFix, datasetCMEKFix},
+	"BUCKET_CMEK_DISABLED":             {bucketCMEKFix},
+	"SQL_CMEK_DISABLED":                {sqlCMEKFix},
+	"DATASET_CMEK_DISABLED":            {datasetCMEKFix},
+}
+
+func setAttribute(path, value string) func(*terraform.Index, terraform.Location) ([]terraform.Change, bool) {
+	return func(sources *terraform.Index, location terraform.Location) ([]terraform.Change, bool) {
+		change, ok := sources.SetAttribute(location, strings.Split(path, "."), value)
+		return []terraform.Change{change}, ok
+	}
+}
+
+func addBlock(path string, attributes ...string) func(*terraform.Index, terraform.Location) ([]terraform.Change, bool) {
+	return func(sources *terraform.Index, location terraform.Location) ([]terraform.Change, bool) {
+		change, ok := sources.AddBlock(location, strings.Split(path, "."), attributes)
+		return []terraform.Change{change}, ok
+	}
+}
+
+func removeBlocks(path string) func(*terraform.Index, terraform.Location) ([]terraform.Change, bool) {
+	return func(sources *terraform.Index, location terraform.Location) ([]terraform.Change, bool) {
+		return sources.RemoveBlocks(location, strings.Split(path, "."))
+	}
+}
+
+// fixes returns the fix of the catalogue for the violated constraint and the
+// type of the resource block, or nil when there is none or it does not apply
+// to the block as written.
+func fixes(violation templates.Violation, sources *terraform.Index) []templates.Fix {
+	location, ok := sourceLocation(violation, sources)
+	if !ok {
+		return nil
+	}
+
+	resourceType, _, _ := strings.Cut(location.Address, ".")
+	for _, f := range fixCatalogue[constraintName(violation.ViolatedPolicy)] {
+		if f.resourceType != resourceType {
+			continue
+		}
+
+		changes, ok := f.changes(sources, location)
+		if !ok {
+			return nil
+		}
+
+		replacements := []templates.Replacement{}
+		for _, change := range changes {
+			replacement := templates.Replacement{
+				DeletedRegion: templates.Region{StartLine: change.StartLine, StartColumn: 1, EndLine: change.EndLine, EndColumn: 1},
+			}
+			if change.Text != "" {
+				replacement.InsertedContent = &templates.ArtifactContent{Text: change.Text}
+			}
+			replacements = append(replacements, replacement)
+		}
+
+		return []templates.Fix{
+			{
+				Description: templates.Message{Text: f.description},
+				ArtifactChanges: []templates.ArtifactChange{
+					{
+						ArtifactLocation: templates.ArtifactLocation{URI: location.URI, URIBaseID: terraform.SRCROOT},
+						Replacements:     replacements,
+					},
+				},
+			},
+		}
+	}
+
+	return nil
+}
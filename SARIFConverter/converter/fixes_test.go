@@ -0,0 +1,190 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/terraform"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+const fixesTF = `resource "google_storage_bucket" "logs" {
+  name                        = "my-logs"
+  uniform_bucket_level_access = false
+}
+
+resource "google_compute_instance" "vm" {
+  network_interface {
+    access_config {}
+  }
+}
+
+resource "google_sql_database_instance" "db" {
+  settings {
+    tier = "db-f1-micro"
+  }
+}
+`
+
+func TestFixes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(fixesTF), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := terraform.IndexSourceDir(dir)
+	if err != nil {
+		t.Fatalf("terraform.IndexSourceDir() failed: %v", err)
+	}
+
+	artifactLocation := templates.ArtifactLocation{URI: "main.tf", URIBaseID: "%SRCROOT%"}
+
+	tests := []struct {
+		name       string
+		assetID    string
+		constraint string
+		sources    *terraform.Index
+		expected   []templates.Fix
+	}{
+		{
+			name:       "SetAttribute",
+			assetID:    "google_storage_bucket.logs",
+			constraint: `{"orgPolicyConstraint":{"cannedConstraintId":"storage.uniformBucketLevelAccess"}}`,
+			sources:    sources,
+			expected: []templates.Fix{
+				{
+					Description: templates.Message{Text: "Enable uniform bucket-level access."},
+					ArtifactChanges: []templates.ArtifactChange{
+						{
+							ArtifactLocation: artifactLocation,
+							Replacements: []templates.Replacement{
+								{
+									DeletedRegion:   templates.Region{StartLine: 3, StartColumn: 1, EndLine: 4, EndColumn: 1},
+									InsertedContent: &templates.ArtifactContent{Text: "  uniform_bucket_level_access = true\n"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:       "AddBlock",
+			assetID:    "google_storage_bucket.logs",
+			constraint: `{"securityHealthAnalyticsModule":{"moduleName":"BUCKET_CMEK_DISABLED"}}`,
+			sources:    sources,
+			expected: []templates.Fix{
+				{
+					Description: templates.Message{Text: "Encrypt the bucket with a customer-managed key, replace the placeholder with your Cloud KMS key."},
+					ArtifactChanges: []templates.ArtifactChange{
+						{
+							ArtifactLocation: artifactLocation,
+							Replacements: []templates.Replacement{
+								{
+									DeletedRegion:   templates.Region{StartLine: 4, StartColumn: 1, EndLine: 4, EndColumn: 1},
+									InsertedContent: &templates.ArtifactContent{Text: "  encryption {\n    default_kms_key_name = " + CMEK_KEY_PLACEHOLDER + "\n  }\n"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:       "RemoveBlocks",
+			assetID:    "google_compute_instance.vm",
+			constraint: `{"securityHealthAnalyticsModule":{"moduleName":"PUBLIC_IP_ADDRESS"}}`,
+			sources:    sources,
+			expected: []templates.Fix{
+				{
+					Description: templates.Message{Text: "Remove the external IP address of the instance."},
+					ArtifactChanges: []templates.ArtifactChange{
+						{
+							ArtifactLocation: artifactLocation,
+							Replacements: []templates.Replacement{
+								{DeletedRegion: templates.Region{StartLine: 8, StartColumn: 1, EndLine: 9, EndColumn: 1}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:       "ResourceTypeSelectsFix",
+			assetID:    "google_sql_database_instance.db",
+			constraint: `{"orgPolicyConstraint":{"cannedConstraintId":"gcp.restrictNonCmekServices"}}`,
+			sources:    sources,
+			expected: []templates.Fix{
+				{
+					Description: templates.Message{Text: "Encrypt the instance with a customer-managed key, replace the placeholder with your Cloud KMS key."},
+					ArtifactChanges: []templates.ArtifactChange{
+						{
+							ArtifactLocation: artifactLocation,
+							Replacements: []templates.Replacement{
+								{
+									DeletedRegion:   templates.Region{StartLine: 16, StartColumn: 1, EndLine: 16, EndColumn: 1},
+									InsertedContent: &templates.ArtifactContent{Text: "  encryption_key_name = " + CMEK_KEY_PLACEHOLDER + "\n"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:       "MissingParentBlock",
+			assetID:    "google_sql_database_instance.db",
+			constraint: `{"orgPolicyConstraint":{"cannedConstraintId":"sql.restrictPublicIp"}}`,
+			sources:    sources,
+		},
+		{
+			name:       "OtherResourceType",
+			assetID:    "google_compute_instance.vm",
+			constraint: `{"orgPolicyConstraint":{"cannedConstraintId":"storage.uniformBucketLevelAccess"}}`,
+			sources:    sources,
+		},
+		{
+			name:       "UnknownConstraint",
+			assetID:    "google_storage_bucket.logs",
+			constraint: `{"orgPolicyConstraint":{"cannedConstraintId":"iam.disableServiceAccountKeyCreation"}}`,
+			sources:    sources,
+		},
+		{
+			name:       "NoSources",
+			assetID:    "google_storage_bucket.logs",
+			constraint: `{"orgPolicyConstraint":{"cannedConstraintId":"storage.uniformBucketLevelAccess"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violation := templates.Violation{
+				AssetID:        test.assetID,
+				ViolatedPolicy: templates.PolicyDetails{Constraint: test.constraint},
+			}
+
+			got := fixes(violation, test.sources)
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("fixes() unexpected result (-want, +got): %v", diff)
+			}
+		})
+	}
+}
@@ -19,7 +19,12 @@ package converter
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
 
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/terraform"
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
 	"github.com/google/gcp-scc-iac-validation-utils/templates"
 )
 
@@ -31,15 +36,63 @@ const (
 	IAC_TOOL_NAME               = "analyze-code-security-scc"
 )
 
-func FromIACScanReport(report templates.IACValidationReport) (templates.SarifOutput, error) {
-	policyToViolationMap := getUniqueViolations(report.Violations)
+// Options configures the conversion. The zero value rejects reports with
+// severities other than CRITICAL, HIGH, MEDIUM and LOW and uses
+// DEFAULT_LEVELS.
+type Options struct {
+	SeverityPolicy severity.Policy
+	// Levels maps severities to SARIF levels, see ParseLevels.
+	Levels map[string]string
+	// Sources, when set, adds the Terraform resource block that declared the
+	// asset to the locations of the results.
+	Sources *terraform.Index
+	// Waivers mark the results they match as suppressed, see ReadWaivers.
+	Waivers []Waiver
+	// Now is the time waivers expire against, the current time when zero.
+	Now time.Time
+	// Baseline, when set, is a previous SARIF report of this tool that the
+	// baselineState of the results is computed against. Only the runs of the
+	// same category are taken into account.
+	Baseline *templates.SarifOutput
+	// Category tells apart the runs of several reports in one SARIF file, e.g.
+	// the Terraform root that was scanned. See automationDetails.
+	Category string
+	// RuleKey is RULE_KEY_POLICY, the default, or RULE_KEY_POLICY_REVISION to
+	// keep the rules of different posture revisions of a policy apart.
+	RuleKey string
+	// FailOnConflict makes FromIACScanReport return a *ConflictError instead
+	// of adding a warning notification when violations of the same rule
+	// disagree on its metadata.
+	FailOnConflict bool
+}
+
+// FromIACScanReport converts the response of the IaC validation operation to
+// a SARIF report with a single run.
+func FromIACScanReport(response templates.Responses, opts Options) (templates.SarifOutput, error) {
+	violations, err := severity.NormalizeViolations(response.IacValidationReport.Violations, opts.SeverityPolicy)
+	if err != nil {
+		return templates.SarifOutput{}, fmt.Errorf("severity.NormalizeViolations: %v", err)
+	}
+
+	conflicts := findConflicts(violations, opts.RuleKey)
+	if len(conflicts) > 0 && opts.FailOnConflict {
+		return templates.SarifOutput{}, &ConflictError{Conflicts: conflicts}
+	}
+
+	policyToViolationMap := getUniqueViolations(violations, opts.RuleKey)
 
-	rules, err := constructRules(policyToViolationMap)
+	rules, err := constructRules(policyToViolationMap, opts)
 	if err != nil {
 		return templates.SarifOutput{}, fmt.Errorf("constructRules: %v", err)
 	}
 
-	results := constructResults(report.Violations)
+	details := automationDetails(response.Name, opts.Category)
+
+	results := constructResults(violations, opts)
+	if opts.Baseline != nil {
+		rules, results = applyBaseline(rules, results, *opts.Baseline, runCategory(templates.Run{AutomationDetails: details}))
+	}
+	setRuleIndexes(rules, results)
 
 	sarifReport := templates.SarifOutput{
 		Version: SARIF_VERSION,
@@ -54,42 +107,73 @@ func FromIACScanReport(report templates.IACValidationReport) (templates.SarifOut
 						Rules:          rules,
 					},
 				},
-				Results: results,
+				Invocations:       constructInvocations(response),
+				AutomationDetails: details,
+				Taxonomies:        constructTaxonomies(policyToViolationMap),
+				Results:           results,
+				Properties:        runProperties(response.Name, violations),
 			},
 		},
 	}
 
+	if len(conflicts) > 0 {
+		sarifReport.Runs[0].Invocations = withNotifications(sarifReport.Runs[0].Invocations, conflictNotifications(conflicts))
+	}
+
+	if opts.Sources != nil {
+		sarifReport.Runs[0].OriginalURIBaseIDs = map[string]templates.ArtifactLocation{
+			terraform.SRCROOT: {URI: "file://" + filepath.ToSlash(opts.Sources.RootDir) + "/"},
+		}
+	}
+
 	return sarifReport, nil
 }
 
-func getUniqueViolations(violations []templates.Violation) map[string]templates.Violation {
+// getUniqueViolations returns the first violation of every rule, keyed by
+// rule ID, see ruleID.
+func getUniqueViolations(violations []templates.Violation, key string) map[string]templates.Violation {
 	policyToViolationMap := make(map[string]templates.Violation)
 
 	for _, violation := range violations {
-		policyID := violation.PolicyID
-		if _, ok := policyToViolationMap[policyID]; !ok {
-			policyToViolationMap[policyID] = violation
+		id := ruleID(violation, key)
+		if _, ok := policyToViolationMap[id]; !ok {
+			policyToViolationMap[id] = violation
 		}
 	}
 
 	return policyToViolationMap
 }
 
-func constructRules(policyToViolationMap map[string]templates.Violation) ([]templates.Rule, error) {
+// constructRules returns one rule per entry of policyToViolationMap, sorted
+// by rule ID.
+func constructRules(policyToViolationMap map[string]templates.Violation, opts Options) ([]templates.Rule, error) {
 	rules := []templates.Rule{}
 
-	for policyID, violation := range policyToViolationMap {
-		if !isSeverityValid(violation.Severity) {
-			return nil, fmt.Errorf("isSeverityValid() invalid severity: %s ", violation.Severity)
+	for id, violation := range policyToViolationMap {
+		policyID := violation.PolicyID
+		ruleSeverity, err := severity.Normalize(violation.Severity, opts.SeverityPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("severity.Normalize: %v", err)
 		}
 
+		uri := helpURI(violation.ViolatedPolicy)
 		rule := templates.Rule{
-			ID: policyID,
+			ID:   id,
+			Name: ruleName(policyID, violation.ViolatedPolicy),
+			ShortDescription: templates.ShortDescription{
+				Text: shortDescription(policyID, violation.ViolatedPolicy),
+			},
 			FullDescription: templates.FullDescription{
 				Text: violation.ViolatedPolicy.Description,
 			},
+			Help:    help(violation, uri),
+			HelpURI: uri,
+			DefaultConfiguration: templates.DefaultConfiguration{
+				Level: levelFor(ruleSeverity, opts.Levels),
+			},
+			Relationships: complianceRelationships(violation.ViolatedPolicy.ComplianceStandards),
 			Properties: templates.RuleProperties{
-				Severity:            violation.Severity,
+				Severity:            ruleSeverity,
 				PolicyType:          violation.ViolatedPolicy.ConstraintType,
 				ComplianceStandard:  violation.ViolatedPolicy.ComplianceStandards,
 				PolicySet:           violation.ViolatedPosture.PolicySet,
@@ -98,26 +182,35 @@ func constructRules(policyToViolationMap map[string]templates.Violation) ([]temp
 				PostureDeploymentID: violation.ViolatedPosture.PostureDeployment,
 				Constraints:         violation.ViolatedPolicy.Constraint,
 				NextSteps:           violation.NextSteps,
+				SecuritySeverity:    SECURITY_SEVERITIES[ruleSeverity],
+				Tags:                complianceTags(violation.ViolatedPolicy.ComplianceStandards),
 			},
 		}
 
 		rules = append(rules, rule)
 	}
 
+	sortRules(rules)
+
 	return rules, nil
 }
 
-func constructResults(violations []templates.Violation) []templates.Result {
+// constructResults expects the severities of violations to be normalised. The
+// results are sorted by policy and asset ID.
+func constructResults(violations []templates.Violation, opts Options) []templates.Result {
 	results := []templates.Result{}
 
 	for _, violation := range violations {
+		fingerprint := violationFingerprint(violation, opts.RuleKey)
 		result := templates.Result{
-			RuleID: violation.PolicyID,
+			RuleID: ruleID(violation, opts.RuleKey),
+			Level:  levelFor(violation.Severity, opts.Levels),
 			Message: templates.Message{
 				Text: fmt.Sprintf("Asset type: %s has a violation, next steps: %s", violation.ViolatedAsset.AssetType, violation.NextSteps),
 			},
 			Locations: []templates.Location{
 				{
+					PhysicalLocation: physicalLocation(violation, opts.Sources),
 					LogicalLocations: []templates.LogicalLocations{
 						{
 							FullyQualifiedName: violation.AssetID,
@@ -125,18 +218,79 @@ func constructResults(violations []templates.Violation) []templates.Result {
 					},
 				},
 			},
+			Fixes:               fixes(violation, opts.Sources),
+			Fingerprints:        map[string]string{FINGERPRINT_KEY: fingerprint},
+			PartialFingerprints: map[string]string{FINGERPRINT_KEY: fingerprint},
+			Suppressions:        suppressions(violation, fingerprint, opts),
 			Properties: templates.ResultProperties{
 				AssetID:   violation.AssetID,
 				Asset:     violation.ViolatedAsset.Asset,
 				AssetType: violation.ViolatedAsset.AssetType,
+				Severity:  violation.Severity,
 			},
 		}
 		results = append(results, result)
 	}
 
+	sortResults(results)
+
 	return results
 }
 
-func isSeverityValid(severity string) bool {
-	return severity == "CRITICAL" || severity == "HIGH" || severity == "MEDIUM" || severity == "LOW" 
+func sortRules(rules []templates.Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+}
+
+// sortResults sorts results by policy and asset ID. Violations of the same
+// policy and asset keep the order of the report.
+func sortResults(results []templates.Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].RuleID != results[j].RuleID {
+			return results[i].RuleID < results[j].RuleID
+		}
+		return results[i].Properties.AssetID < results[j].Properties.AssetID
+	})
+}
+
+// setRuleIndexes points every result at its rule in the rules of the driver.
+func setRuleIndexes(rules []templates.Rule, results []templates.Result) {
+	ruleIndexes := make(map[string]int)
+	for i, rule := range rules {
+		ruleIndexes[rule.ID] = i
+	}
+
+	for i := range results {
+		results[i].RuleIndex = ruleIndexes[results[i].RuleID]
+	}
+}
+
+// sourceLocation returns the resource block that declared the asset of
+// violation, if sources are given.
+func sourceLocation(violation templates.Violation, sources *terraform.Index) (terraform.Location, bool) {
+	if sources == nil {
+		return terraform.Location{}, false
+	}
+	return sources.Lookup(violation.AssetID, violation.ViolatedAsset.Asset)
+}
+
+// physicalLocation returns the resource block that declared the asset of
+// violation, or nil when it can't be resolved.
+func physicalLocation(violation templates.Violation, sources *terraform.Index) *templates.PhysicalLocation {
+	location, ok := sourceLocation(violation, sources)
+	if !ok {
+		return nil
+	}
+
+	return &templates.PhysicalLocation{
+		ArtifactLocation: templates.ArtifactLocation{
+			URI:       location.URI,
+			URIBaseID: terraform.SRCROOT,
+		},
+		Region: templates.Region{
+			StartLine: location.StartLine,
+			EndLine:   location.EndLine,
+		},
+	}
 }
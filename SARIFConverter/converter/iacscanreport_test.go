@@ -17,10 +17,16 @@
 package converter
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/terraform"
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
 	"github.com/google/gcp-scc-iac-validation-utils/templates"
 )
 
@@ -28,6 +34,7 @@ func TestGenerateReport(t *testing.T) {
 	tests := []struct {
 		name                string
 		validationReport templates.IACValidationReport
+		opts             Options
 		wantError       bool
 		wantOutput      templates.SarifOutput
 	}{
@@ -43,11 +50,24 @@ func TestGenerateReport(t *testing.T) {
 			wantOutput:      templates.SarifOutput{},
 			wantError:       true,
 		},
+		{
+			name:             "InvalidSeverityReport_MappedToHigh_Succeeds",
+			validationReport: IACValidationReportWithInvalidSeverity,
+			opts:             Options{SeverityPolicy: severity.Policy{Mode: severity.MODE_MAP, MapTo: severity.HIGH}},
+			wantOutput:       IACValidSarifOutput,
+			wantError:        false,
+		},
+		{
+			name:             "LowercaseSeverityReport_Succeeds",
+			validationReport: IACValidationReportWithLowercaseSeverity,
+			wantOutput:       IACValidSarifOutput,
+			wantError:        false,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			actualOutput, err := FromIACScanReport(test.validationReport)
+			actualOutput, err := FromIACScanReport(templates.Responses{IacValidationReport: test.validationReport}, test.opts)
 
 			if (err != nil) != test.wantError {
 				t.Errorf("Expected error: %v, got: %v", test.wantError, err)
@@ -100,7 +120,7 @@ func TestGetUniqueViolations(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := getUniqueViolations(tc.input)
+			result := getUniqueViolations(tc.input, RULE_KEY_POLICY)
 
 			if diff := cmp.Diff(tc.expected, result); diff != "" {
 				t.Errorf("Expected %v, (-want, +got)", diff)
@@ -139,17 +159,21 @@ func TestConstructRules(t *testing.T) {
 			},
 			expected: []templates.Rule{
 				{
-					ID:              "policy2",
-					FullDescription: templates.FullDescription{Text: "Description 2"},
-					Properties: templates.RuleProperties{
-						Severity:   "MEDIUM",
-						PolicyType: "Type 2",
-						NextSteps:  "Next steps 2",
+					ID:               "policy1",
+					Name:             "policy1",
+					ShortDescription: templates.ShortDescription{Text: "Description 1"},
+					FullDescription:  templates.FullDescription{Text: "Description 1"},
+					Help: &templates.Help{
+						Text:     "Next steps: Next steps 1\nCompliance standards: Standard 1",
+						Markdown: "**Next steps:** Next steps 1\n\n**Compliance standards:**\n\n- Standard 1",
+					},
+					DefaultConfiguration: templates.DefaultConfiguration{Level: "error"},
+					Relationships: []templates.Relationship{
+						{
+							Target: templates.DescriptorReference{ID: "1", ToolComponent: templates.ToolComponentReference{Name: "Standard"}},
+							Kinds:  []string{"relevant"},
+						},
 					},
-				},
-				{
-					ID:              "policy1",
-					FullDescription: templates.FullDescription{Text: "Description 1"},
 					Properties: templates.RuleProperties{
 						Severity:            "HIGH",
 						PolicyType:          "Type 1",
@@ -159,6 +183,26 @@ func TestConstructRules(t *testing.T) {
 						PostureRevisionID:   "Rev 1",
 						PostureDeploymentID: "Dep 1",
 						NextSteps:           "Next steps 1",
+						SecuritySeverity:    "8.0",
+						Tags:                []string{"security", "Standard", "Standard 1"},
+					},
+				},
+				{
+					ID:               "policy2",
+					Name:             "policy2",
+					ShortDescription: templates.ShortDescription{Text: "Description 2"},
+					FullDescription:  templates.FullDescription{Text: "Description 2"},
+					Help: &templates.Help{
+						Text:     "Next steps: Next steps 2",
+						Markdown: "**Next steps:** Next steps 2",
+					},
+					DefaultConfiguration: templates.DefaultConfiguration{Level: "warning"},
+					Properties: templates.RuleProperties{
+						Severity:         "MEDIUM",
+						PolicyType:       "Type 2",
+						NextSteps:        "Next steps 2",
+						SecuritySeverity: "5.5",
+						Tags:             []string{"security"},
 					},
 				},
 			},
@@ -176,10 +220,19 @@ func TestConstructRules(t *testing.T) {
 			},
 			expected: []templates.Rule{
 				{
-					ID: "policy3",
+					ID:               "policy3",
+					Name:             "policy3",
+					ShortDescription: templates.ShortDescription{Text: "policy3"},
+					Help: &templates.Help{
+						Text:     "Next steps: Next steps 3",
+						Markdown: "**Next steps:** Next steps 3",
+					},
+					DefaultConfiguration: templates.DefaultConfiguration{Level: "note"},
 					Properties: templates.RuleProperties{
-						Severity:  "LOW",
-						NextSteps: "Next steps 3",
+						Severity:         "LOW",
+						NextSteps:        "Next steps 3",
+						SecuritySeverity: "2.0",
+						Tags:             []string{"security"},
 					},
 				},
 			},
@@ -188,7 +241,7 @@ func TestConstructRules(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := constructRules(tc.input)
+			result, err := constructRules(tc.input, Options{})
 			if err != nil {
 				t.Fatalf("constructRules(%v) failed: %v", tc.input, err)
 			}
@@ -230,6 +283,8 @@ func TestConstructResults(t *testing.T) {
 							},
 						},
 					},
+					Fingerprints:        map[string]string{"sccIacViolation/v1": "57d44e85c7c5ee68e64830360e894f81250b32de0c919ab85577720dc55ba3ca"},
+					PartialFingerprints: map[string]string{"sccIacViolation/v1": "57d44e85c7c5ee68e64830360e894f81250b32de0c919ab85577720dc55ba3ca"},
 					Properties: templates.ResultProperties{
 						AssetID: "asset1",
 					},
@@ -242,12 +297,14 @@ func TestConstructResults(t *testing.T) {
 				{
 					PolicyID:      "policy1",
 					AssetID:       "asset1",
+					Severity:      "CRITICAL",
 					NextSteps:     "next_steps1",
 					ViolatedAsset: templates.AssetDetails{AssetType: "type1", Asset: "asset1"},
 				},
 				{
 					PolicyID:      "policy2",
 					AssetID:       "asset2",
+					Severity:      "LOW",
 					NextSteps:     "next_steps2",
 					ViolatedAsset: templates.AssetDetails{AssetType: "type2", Asset: "asset2"},
 				},
@@ -255,6 +312,7 @@ func TestConstructResults(t *testing.T) {
 			expected: []templates.Result{
 				{
 					RuleID:  "policy1",
+					Level:   "error",
 					Message: templates.Message{Text: "Asset type: type1 has a violation, next steps: next_steps1"},
 					Locations: []templates.Location{
 						{
@@ -263,14 +321,18 @@ func TestConstructResults(t *testing.T) {
 							},
 						},
 					},
+					Fingerprints:        map[string]string{"sccIacViolation/v1": "57d44e85c7c5ee68e64830360e894f81250b32de0c919ab85577720dc55ba3ca"},
+					PartialFingerprints: map[string]string{"sccIacViolation/v1": "57d44e85c7c5ee68e64830360e894f81250b32de0c919ab85577720dc55ba3ca"},
 					Properties: templates.ResultProperties{
 						AssetID:   "asset1",
 						Asset:     "asset1",
 						AssetType: "type1",
+						Severity:  "CRITICAL",
 					},
 				},
 				{
 					RuleID:  "policy2",
+					Level:   "note",
 					Message: templates.Message{Text: "Asset type: type2 has a violation, next steps: next_steps2"},
 					Locations: []templates.Location{
 						{
@@ -279,10 +341,13 @@ func TestConstructResults(t *testing.T) {
 							},
 						},
 					},
+					Fingerprints:        map[string]string{"sccIacViolation/v1": "737be586bb16186b908df142e427a791ce09b26262202bb459d112fdc81ee38b"},
+					PartialFingerprints: map[string]string{"sccIacViolation/v1": "737be586bb16186b908df142e427a791ce09b26262202bb459d112fdc81ee38b"},
 					Properties: templates.ResultProperties{
 						AssetID:   "asset2",
 						Asset:     "asset2",
 						AssetType: "type2",
+						Severity:  "LOW",
 					},
 				},
 			},
@@ -291,7 +356,7 @@ func TestConstructResults(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := constructResults(tc.input)
+			result := constructResults(tc.input, Options{})
 
 			if diff := cmp.Diff(tc.expected, result); diff != "" {
 				t.Errorf("Expected %v, (-want, +got)", diff)
@@ -299,3 +364,92 @@ func TestConstructResults(t *testing.T) {
 		})
 	}
 }
+
+func TestConstructResultsWithSources(t *testing.T) {
+	dir := t.TempDir()
+	content := "resource \"google_storage_bucket\" \"logs\" {\n  name = \"my-logs\"\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := terraform.IndexSourceDir(dir)
+	if err != nil {
+		t.Fatalf("terraform.IndexSourceDir() failed: %v", err)
+	}
+
+	violations := []templates.Violation{
+		{PolicyID: "policy1", AssetID: "google_storage_bucket.logs"},
+		{PolicyID: "policy1", AssetID: "google_storage_bucket.other"},
+	}
+
+	want := []templates.Location{
+		{
+			PhysicalLocation: &templates.PhysicalLocation{
+				ArtifactLocation: templates.ArtifactLocation{URI: "main.tf", URIBaseID: "%SRCROOT%"},
+				Region:           templates.Region{StartLine: 1, EndLine: 3},
+			},
+			LogicalLocations: []templates.LogicalLocations{{FullyQualifiedName: "google_storage_bucket.logs"}},
+		},
+		{
+			LogicalLocations: []templates.LogicalLocations{{FullyQualifiedName: "google_storage_bucket.other"}},
+		},
+	}
+
+	results := constructResults(violations, Options{Sources: sources})
+
+	got := []templates.Location{}
+	for _, result := range results {
+		got = append(got, result.Locations...)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected locations (-want, +got): %v", diff)
+	}
+}
+
+func TestGenerateReportIsDeterministic(t *testing.T) {
+	report := templates.IACValidationReport{
+		Violations: []templates.Violation{
+			{PolicyID: "P3", AssetID: "asset2", Severity: "LOW"},
+			{PolicyID: "P1", AssetID: "asset2", Severity: "HIGH"},
+			{PolicyID: "P2", AssetID: "asset1", Severity: "MEDIUM"},
+			{PolicyID: "P1", AssetID: "asset1", Severity: "HIGH"},
+			{PolicyID: "P3", AssetID: "asset1", Severity: "LOW"},
+		},
+	}
+
+	first, err := FromIACScanReport(templates.Responses{IacValidationReport: report}, Options{})
+	if err != nil {
+		t.Fatalf("FromIACScanReport() failed: %v", err)
+	}
+	want, err := json.Marshal(first)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		output, err := FromIACScanReport(templates.Responses{IacValidationReport: report}, Options{})
+		if err != nil {
+			t.Fatalf("FromIACScanReport() failed: %v", err)
+		}
+		got, err := json.Marshal(output)
+		if err != nil {
+			t.Fatalf("json.Marshal() failed: %v", err)
+		}
+		if !bytes.Equal(want, got) {
+			t.Fatalf("FromIACScanReport() output differs between runs:\n%s\n%s", want, got)
+		}
+	}
+
+	run := first.Runs[0]
+	gotOrder := []string{}
+	for _, result := range run.Results {
+		if rule := run.Tool.Driver.Rules[result.RuleIndex]; rule.ID != result.RuleID {
+			t.Errorf("result %s/%s has ruleIndex %d pointing at rule %s", result.RuleID, result.Properties.AssetID, result.RuleIndex, rule.ID)
+		}
+		gotOrder = append(gotOrder, result.RuleID+"/"+result.Properties.AssetID)
+	}
+
+	wantOrder := []string{"P1/asset1", "P1/asset2", "P2/asset1", "P3/asset1", "P3/asset2"}
+	if diff := cmp.Diff(wantOrder, gotOrder); diff != "" {
+		t.Errorf("Unexpected result order (-want, +got): %v", diff)
+	}
+}
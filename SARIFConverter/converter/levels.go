@@ -0,0 +1,98 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+)
+
+// SARIF result levels.
+const (
+	LEVEL_ERROR   = "error"
+	LEVEL_WARNING = "warning"
+	LEVEL_NOTE    = "note"
+	LEVEL_NONE    = "none"
+)
+
+// SECURITY_TAG marks rules as security rules, GitHub code scanning only ranks
+// alerts of such rules by their security-severity.
+const SECURITY_TAG = "security"
+
+// DEFAULT_LEVELS maps severities to the SARIF level of their results.
+var DEFAULT_LEVELS = map[string]string{
+	severity.CRITICAL: LEVEL_ERROR,
+	severity.HIGH:     LEVEL_ERROR,
+	severity.MEDIUM:   LEVEL_WARNING,
+	severity.LOW:      LEVEL_NOTE,
+	severity.UNKNOWN:  LEVEL_WARNING,
+}
+
+// SECURITY_SEVERITIES maps severities to the numeric security-severity rule
+// property. GitHub ranks scores above 9.0 as critical, from 7.0 as high, from
+// 4.0 as medium and below as low. UNKNOWN has no score on purpose.
+var SECURITY_SEVERITIES = map[string]string{
+	severity.CRITICAL: "9.5",
+	severity.HIGH:     "8.0",
+	severity.MEDIUM:   "5.5",
+	severity.LOW:      "2.0",
+}
+
+// ParseLevels parses a comma separated list of SEVERITY:level pairs, e.g.
+// "high:warning,low:none", and returns DEFAULT_LEVELS with those overrides.
+func ParseLevels(expression string) (map[string]string, error) {
+	levels := make(map[string]string)
+	for s, level := range DEFAULT_LEVELS {
+		levels[s] = level
+	}
+
+	if strings.TrimSpace(expression) == "" {
+		return levels, nil
+	}
+
+	for _, pair := range strings.Split(expression, ",") {
+		s, level, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid severity level pair: %s", pair)
+		}
+
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if _, ok := DEFAULT_LEVELS[s]; !ok {
+			return nil, fmt.Errorf("invalid severity: %s", s)
+		}
+
+		level = strings.ToLower(strings.TrimSpace(level))
+		if level != LEVEL_ERROR && level != LEVEL_WARNING && level != LEVEL_NOTE && level != LEVEL_NONE {
+			return nil, fmt.Errorf("invalid SARIF level: %s", level)
+		}
+
+		levels[s] = level
+	}
+
+	return levels, nil
+}
+
+// levelFor returns the SARIF level of severity, using DEFAULT_LEVELS when no
+// mapping is configured.
+func levelFor(s string, levels map[string]string) string {
+	if levels == nil {
+		levels = DEFAULT_LEVELS
+	}
+	return levels[s]
+}
@@ -0,0 +1,77 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseLevels(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		expected   map[string]string
+		wantError  bool
+	}{
+		{
+			name:       "Empty_ReturnsDefaults",
+			expression: "",
+			expected:   DEFAULT_LEVELS,
+		},
+		{
+			name:       "Overrides_Succeeds",
+			expression: "high:Warning, low:none",
+			expected: map[string]string{
+				"CRITICAL": "error",
+				"HIGH":     "warning",
+				"MEDIUM":   "warning",
+				"LOW":      "none",
+				"UNKNOWN":  "warning",
+			},
+		},
+		{
+			name:       "InvalidLevel_Failure",
+			expression: "high:fatal",
+			wantError:  true,
+		},
+		{
+			name:       "InvalidSeverity_Failure",
+			expression: "severe:error",
+			wantError:  true,
+		},
+		{
+			name:       "MissingLevel_Failure",
+			expression: "high",
+			wantError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLevels(test.expression)
+			if (err != nil) != test.wantError {
+				t.Errorf("Expected error: %v, got: %v", test.wantError, err)
+			}
+
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("Unexpected levels (-want, +got): %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// GitHub code scanning limits, see
+// https://docs.github.com/en/code-security/code-scanning/integrating-with-code-scanning/sarif-support-for-code-scanning#file-limits
+const (
+	GITHUB_MAX_RESULTS_PER_RUN = 25000
+	GITHUB_MAX_RUNS_PER_FILE   = 20
+	GITHUB_MAX_FILE_SIZE       = 10 * 1024 * 1024
+)
+
+// PART_CATEGORY_PREFIX names the category of the parts Split divides runs
+// into, e.g. part-2.
+const PART_CATEGORY_PREFIX = "part-"
+
+// DEFAULT_PARTS_PER_RUN is the number of parts Split divides runs into when
+// the output has file limits. It must stay the same from one scan to the
+// next, as the parts a result ends up in depend on it.
+const DEFAULT_PARTS_PER_RUN = 10
+
+// LimitResults keeps at most maxPerRule results of each rule and maxPerRun
+// results of each run, zero meaning no limit. Results that are neither
+// suppressed nor absent are kept first, the most severe first. Every run
+// that lost results gets a warning notification per rule and per limit, and
+// the number of dropped results in its properties.
+func LimitResults(sarifReport templates.SarifOutput, maxPerRule, maxPerRun int) templates.SarifOutput {
+	runs := []templates.Run{}
+	for _, run := range sarifReport.Runs {
+		runs = append(runs, limitRun(run, maxPerRule, maxPerRun))
+	}
+	sarifReport.Runs = runs
+	return sarifReport
+}
+
+func limitRun(run templates.Run, maxPerRule, maxPerRun int) templates.Run {
+	rules := run.Tool.Driver.Rules
+	ruleSeverities := make(map[string]string)
+	for _, rule := range rules {
+		ruleSeverities[rule.ID] = rule.Properties.Severity
+	}
+
+	order := make([]int, len(run.Results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := run.Results[order[i]], run.Results[order[j]]
+		if isActive(a) != isActive(b) {
+			return isActive(a)
+		}
+		return severity.Rank(resultSeverity(a, ruleSeverities)) < severity.Rank(resultSeverity(b, ruleSeverities))
+	})
+
+	kept := make([]bool, len(run.Results))
+	perRule := make(map[string]int)
+	droppedPerRule := make(map[string]int)
+	droppedPerRun := 0
+	total := 0
+	for _, i := range order {
+		ruleID := run.Results[i].RuleID
+		switch {
+		case maxPerRule > 0 && perRule[ruleID] >= maxPerRule:
+			droppedPerRule[ruleID]++
+		case maxPerRun > 0 && total >= maxPerRun:
+			droppedPerRun++
+		default:
+			kept[i] = true
+			perRule[ruleID]++
+			total++
+		}
+	}
+
+	if total == len(run.Results) {
+		return run
+	}
+
+	results := []templates.Result{}
+	for i, result := range run.Results {
+		if kept[i] {
+			results = append(results, result)
+		}
+	}
+
+	notifications := []templates.Notification{}
+	ruleIDs := []string{}
+	for ruleID := range droppedPerRule {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+	for _, ruleID := range ruleIDs {
+		notifications = append(notifications, templates.Notification{
+			Level:   LEVEL_WARNING,
+			Message: templates.Message{Text: fmt.Sprintf("Dropped %d results of rule %s over the limit of %d results per rule.", droppedPerRule[ruleID], ruleID, maxPerRule)},
+		})
+	}
+	if droppedPerRun > 0 {
+		notifications = append(notifications, templates.Notification{
+			Level:   LEVEL_WARNING,
+			Message: templates.Message{Text: fmt.Sprintf("Dropped %d results over the limit of %d results per run.", droppedPerRun, maxPerRun)},
+		})
+	}
+
+	run.Properties.DroppedResults += len(run.Results) - total
+	run.Results = results
+	setRuleIndexes(rules, run.Results)
+	run.Invocations = withNotifications(run.Invocations, notifications)
+
+	return run
+}
+
+// resultSeverity is the severity of the violation of result, falling back to
+// the severity of its rule for reports written before results had one.
+func resultSeverity(result templates.Result, ruleSeverities map[string]string) string {
+	if result.Properties.Severity != "" {
+		return result.Properties.Severity
+	}
+	return ruleSeverities[result.RuleID]
+}
+
+// isActive reports whether result is neither suppressed nor absent.
+func isActive(result templates.Result) bool {
+	return len(result.Suppressions) == 0 && result.BaselineState != BASELINE_STATE_ABSENT
+}
+
+// withNotifications returns a copy of invocations with notifications added to
+// the first invocation, creating one if needed.
+func withNotifications(invocations []templates.Invocation, notifications []templates.Notification) []templates.Invocation {
+	invocations = append([]templates.Invocation{}, invocations...)
+	if len(invocations) == 0 {
+		invocations = append(invocations, templates.Invocation{ExecutionSuccessful: true})
+	}
+	invocations[0].ToolExecutionNotifications = append(append([]templates.Notification{}, invocations[0].ToolExecutionNotifications...), notifications...)
+	return invocations
+}
+
+// Split divides sarifReport into reports with at most maxResults results and
+// at most maxSize bytes once gzip compressed, zero meaning no limit, and at
+// most GITHUB_MAX_RUNS_PER_FILE runs. With either limit set, every run is
+// divided into partsPerRun parts, see splitRun, each with the rules its
+// results refer to and a category of its own, e.g.
+// analyze-code-security-scc/part-2/abc, as code scanning replaces analyses of
+// the same category. The properties of every part count the violations of its
+// results, except that the first part also counts the results LimitResults
+// dropped, so that the counts of the parts add up to those of the run. A part
+// that exceeds the limits on its own is an error.
+func Split(sarifReport templates.SarifOutput, maxResults, maxSize, partsPerRun int) ([]templates.SarifOutput, error) {
+	parts := []templates.Run{}
+	for _, run := range sarifReport.Runs {
+		if (maxResults == 0 && maxSize == 0) || partsPerRun <= 1 {
+			parts = append(parts, run)
+			continue
+		}
+
+		runParts := splitRun(run, partsPerRun)
+		runParts[0].Properties = withRemainder(runParts[0].Properties, run.Properties, runParts)
+		parts = append(parts, runParts...)
+	}
+
+	reports := []templates.SarifOutput{}
+	current := withRuns(sarifReport, nil)
+	results := 0
+	for _, part := range parts {
+		if err := checkPart(sarifReport, part, maxResults, maxSize); err != nil {
+			return nil, err
+		}
+
+		candidate := withRuns(sarifReport, append(append([]templates.Run{}, current.Runs...), part))
+		fits := len(candidate.Runs) <= GITHUB_MAX_RUNS_PER_FILE && (maxResults == 0 || results+len(part.Results) <= maxResults)
+		if fits && maxSize > 0 {
+			size, err := compressedSize(candidate)
+			if err != nil {
+				return nil, err
+			}
+			fits = size <= maxSize
+		}
+
+		if fits || len(current.Runs) == 0 {
+			current = candidate
+			results += len(part.Results)
+			continue
+		}
+
+		reports = append(reports, current)
+		current = withRuns(sarifReport, []templates.Run{part})
+		results = len(part.Results)
+	}
+	reports = append(reports, current)
+
+	return reports, nil
+}
+
+// checkPart fails when part alone doesn't fit into a report of maxResults
+// results and maxSize bytes.
+func checkPart(sarifReport templates.SarifOutput, part templates.Run, maxResults, maxSize int) error {
+	if maxResults > 0 && len(part.Results) > maxResults {
+		return fmt.Errorf("the run of category %s has %d results, more than %d: divide runs into more parts", runCategory(part), len(part.Results), maxResults)
+	}
+	if maxSize > 0 {
+		size, err := compressedSize(withRuns(sarifReport, []templates.Run{part}))
+		if err != nil {
+			return err
+		}
+		if size > maxSize {
+			return fmt.Errorf("the run of category %s has %d bytes compressed, more than %d: divide runs into more parts", runCategory(part), size, maxSize)
+		}
+	}
+	return nil
+}
+
+// splitRun divides the results of run into parts by a hash of their
+// fingerprints, so that a result stays in the same part, and thus in the same
+// category, from one scan to the next. All parts are returned, also those
+// without results, so that every scan replaces the analyses of all of them.
+func splitRun(run templates.Run, partsPerRun int) []templates.Run {
+	partResults := make([][]templates.Result, partsPerRun)
+	for _, result := range run.Results {
+		i := partOf(result, partsPerRun)
+		partResults[i] = append(partResults[i], result)
+	}
+
+	parts := []templates.Run{}
+	for i, results := range partResults {
+		part := runPart(run, results)
+		part.AutomationDetails = partAutomationDetails(run.AutomationDetails, i+1)
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// partOf returns the index of the part of result, hashing its fingerprint or,
+// for results of other tools without one, its rule ID.
+func partOf(result templates.Result, partsPerRun int) int {
+	key := resultFingerprint(result)
+	if key == "" {
+		key = result.RuleID
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(partsPerRun))
+}
+
+// runPart returns run with only results and the rules they refer to.
+func runPart(run templates.Run, results []templates.Result) templates.Run {
+	ruleIDs := make(map[string]bool)
+	for _, result := range results {
+		ruleIDs[result.RuleID] = true
+	}
+
+	rules := []templates.Rule{}
+	for _, rule := range run.Tool.Driver.Rules {
+		if ruleIDs[rule.ID] {
+			rules = append(rules, rule)
+		}
+	}
+
+	results = append([]templates.Result{}, results...)
+	setRuleIndexes(rules, results)
+
+	run.Tool.Driver.Rules = rules
+	run.Results = results
+	run.Properties = partProperties(run.Properties, rules, results)
+	return run
+}
+
+// partProperties counts the violations of results by severity, like
+// runProperties does for the whole report.
+func partProperties(properties templates.RunProperties, rules []templates.Rule, results []templates.Result) templates.RunProperties {
+	ruleSeverities := make(map[string]string)
+	for _, rule := range rules {
+		ruleSeverities[rule.ID] = rule.Properties.Severity
+	}
+
+	severityCounts := make(map[string]int)
+	for _, s := range severity.Known {
+		severityCounts[s] = 0
+	}
+	for _, result := range results {
+		if s := resultSeverity(result, ruleSeverities); s != "" {
+			severityCounts[s]++
+		}
+	}
+
+	return templates.RunProperties{
+		ReportName:      properties.ReportName,
+		SeverityCounts:  severityCounts,
+		TotalViolations: len(results),
+	}
+}
+
+// withRemainder adds to properties what the counts of run exceed those of
+// parts by, i.e. the dropped results.
+func withRemainder(properties, run templates.RunProperties, parts []templates.Run) templates.RunProperties {
+	severityCounts := make(map[string]int)
+	for s, count := range properties.SeverityCounts {
+		severityCounts[s] = count
+	}
+
+	total := run.TotalViolations
+	remainders := make(map[string]int)
+	for s, count := range run.SeverityCounts {
+		remainders[s] = count
+	}
+	for _, part := range parts {
+		total -= part.Properties.TotalViolations
+		for s, count := range part.Properties.SeverityCounts {
+			remainders[s] -= count
+		}
+	}
+
+	for s, remainder := range remainders {
+		if remainder > 0 {
+			severityCounts[s] += remainder
+		}
+	}
+	if total > 0 {
+		properties.TotalViolations += total
+	}
+	properties.SeverityCounts = severityCounts
+	properties.DroppedResults = run.DroppedResults
+	return properties
+}
+
+// partAutomationDetails inserts the part number into the category of the
+// automation ID, see automationDetails.
+func partAutomationDetails(details *templates.AutomationDetails, part int) *templates.AutomationDetails {
+	category := fmt.Sprintf("%s%d", PART_CATEGORY_PREFIX, part)
+	if details == nil {
+		return &templates.AutomationDetails{ID: IAC_TOOL_NAME + "/" + category + "/"}
+	}
+
+	id := details.ID
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		return &templates.AutomationDetails{ID: id[:i+1] + category + "/" + id[i+1:]}
+	}
+	return &templates.AutomationDetails{ID: id + "/" + category + "/"}
+}
+
+func withRuns(sarifReport templates.SarifOutput, runs []templates.Run) templates.SarifOutput {
+	if runs == nil {
+		runs = []templates.Run{}
+	}
+	sarifReport.Runs = runs
+	return sarifReport
+}
+
+// compressedSize returns the gzip compressed size of sarifReport as written
+// by SARIFConverter.
+func compressedSize(sarifReport templates.SarifOutput) (int, error) {
+	sarifJSON, err := json.MarshalIndent(sarifReport, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("json.MarshalIndent: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(sarifJSON); err != nil {
+		return 0, fmt.Errorf("writer.Write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return 0, fmt.Errorf("writer.Close: %v", err)
+	}
+
+	return buffer.Len(), nil
+}
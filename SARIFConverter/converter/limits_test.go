@@ -0,0 +1,402 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// limitsReport has policies P1 (LOW, 3 results), P2 (CRITICAL, 2 results)
+// and P3 (HIGH, 1 suppressed result).
+func limitsReport(t *testing.T) templates.SarifOutput {
+	t.Helper()
+
+	sarifReport, err := FromIACScanReport(templates.Responses{
+		Name: "organizations/1/locations/global/reports/abc",
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				{PolicyID: "P1", AssetID: "a1", Severity: "LOW"},
+				{PolicyID: "P1", AssetID: "a2", Severity: "LOW"},
+				{PolicyID: "P1", AssetID: "a3", Severity: "LOW"},
+				{PolicyID: "P2", AssetID: "a1", Severity: "CRITICAL"},
+				{PolicyID: "P2", AssetID: "a2", Severity: "CRITICAL"},
+				{PolicyID: "P3", AssetID: "a1", Severity: "HIGH"},
+			},
+		},
+	}, Options{Waivers: []Waiver{{PolicyID: "P3", Justification: "accepted"}}})
+	if err != nil {
+		t.Fatalf("FromIACScanReport() failed: %v", err)
+	}
+	return sarifReport
+}
+
+type resultKey struct {
+	RuleID    string
+	RuleIndex int
+	AssetID   string
+}
+
+func resultKeys(results []templates.Result) []resultKey {
+	keys := []resultKey{}
+	for _, result := range results {
+		keys = append(keys, resultKey{result.RuleID, result.RuleIndex, result.Properties.AssetID})
+	}
+	return keys
+}
+
+func TestLimitResults(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxPerRule        int
+		maxPerRun         int
+		wantResults       []resultKey
+		wantNotifications []string
+		wantDropped       int
+	}{
+		{
+			name:       "NoLimits",
+			maxPerRule: 0,
+			maxPerRun:  0,
+			wantResults: []resultKey{
+				{"P1", 0, "a1"}, {"P1", 0, "a2"}, {"P1", 0, "a3"},
+				{"P2", 1, "a1"}, {"P2", 1, "a2"},
+				{"P3", 2, "a1"},
+			},
+		},
+		{
+			name:       "PerRule",
+			maxPerRule: 2,
+			wantResults: []resultKey{
+				{"P1", 0, "a1"}, {"P1", 0, "a2"},
+				{"P2", 1, "a1"}, {"P2", 1, "a2"},
+				{"P3", 2, "a1"},
+			},
+			wantNotifications: []string{"Dropped 1 results of rule P1 over the limit of 2 results per rule."},
+			wantDropped:       1,
+		},
+		{
+			name:      "PerRunKeepsActiveThenMostSevere",
+			maxPerRun: 3,
+			wantResults: []resultKey{
+				{"P1", 0, "a1"},
+				{"P2", 1, "a1"}, {"P2", 1, "a2"},
+			},
+			wantNotifications: []string{"Dropped 3 results over the limit of 3 results per run."},
+			wantDropped:       3,
+		},
+		{
+			name:       "PerRuleAndPerRun",
+			maxPerRule: 1,
+			maxPerRun:  2,
+			wantResults: []resultKey{
+				{"P1", 0, "a1"},
+				{"P2", 1, "a1"},
+			},
+			wantNotifications: []string{
+				"Dropped 2 results of rule P1 over the limit of 1 results per rule.",
+				"Dropped 1 results of rule P2 over the limit of 1 results per rule.",
+				"Dropped 1 results over the limit of 2 results per run.",
+			},
+			wantDropped: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := LimitResults(limitsReport(t), test.maxPerRule, test.maxPerRun)
+			run := got.Runs[0]
+
+			if diff := cmp.Diff(test.wantResults, resultKeys(run.Results)); diff != "" {
+				t.Errorf("LimitResults() unexpected results (-want, +got): %v", diff)
+			}
+
+			notifications := []string{}
+			for _, notification := range run.Invocations[0].ToolExecutionNotifications {
+				if notification.Level != LEVEL_WARNING {
+					t.Errorf("LimitResults() notification level = %q, want %q", notification.Level, LEVEL_WARNING)
+				}
+				notifications = append(notifications, notification.Message.Text)
+			}
+			if len(test.wantNotifications) == 0 {
+				test.wantNotifications = []string{}
+			}
+			if diff := cmp.Diff(test.wantNotifications, notifications); diff != "" {
+				t.Errorf("LimitResults() unexpected notifications (-want, +got): %v", diff)
+			}
+
+			if run.Properties.DroppedResults != test.wantDropped {
+				t.Errorf("LimitResults() dropped = %d, want %d", run.Properties.DroppedResults, test.wantDropped)
+			}
+		})
+	}
+}
+
+func TestLimitResults_ActiveFirst(t *testing.T) {
+	sarifReport := limitsReport(t)
+	run := &sarifReport.Runs[0]
+	// P2 (CRITICAL) is fixed, P3 (HIGH) suppressed and P1 (LOW) active.
+	for i := range run.Results {
+		if run.Results[i].RuleID == "P2" {
+			run.Results[i].BaselineState = BASELINE_STATE_ABSENT
+		}
+	}
+	run.Results = append(run.Results, templates.Result{RuleID: "P4", Properties: templates.ResultProperties{AssetID: "a1", Severity: "HIGH"}})
+	run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, templates.Rule{ID: "P4", Properties: templates.RuleProperties{Severity: "HIGH"}})
+
+	got := LimitResults(sarifReport, 0, 2)
+
+	want := []resultKey{{"P1", 0, "a1"}, {"P4", 3, "a1"}}
+	if diff := cmp.Diff(want, resultKeys(got.Runs[0].Results)); diff != "" {
+		t.Errorf("LimitResults() unexpected results (-want, +got): %v", diff)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	sarifReport := limitsReport(t)
+
+	t.Run("FitsIntoOneReport", func(t *testing.T) {
+		got, err := Split(sarifReport, 0, 0, DEFAULT_PARTS_PER_RUN)
+		if err != nil {
+			t.Fatalf("Split() failed: %v", err)
+		}
+		if diff := cmp.Diff([]templates.SarifOutput{sarifReport}, got); diff != "" {
+			t.Errorf("Split() unexpected result (-want, +got): %v", diff)
+		}
+	})
+
+	t.Run("MaxResults", func(t *testing.T) {
+		got, err := Split(sarifReport, 4, 0, 3)
+		if err != nil {
+			t.Fatalf("Split() failed: %v", err)
+		}
+
+		type part struct {
+			AutomationID string
+			RuleIDs      []string
+			Results      []resultKey
+		}
+		want := [][]part{
+			{
+				{
+					AutomationID: "analyze-code-security-scc/part-1/abc",
+					RuleIDs:      []string{"P2"},
+					Results:      []resultKey{{"P2", 0, "a1"}},
+				},
+				{
+					AutomationID: "analyze-code-security-scc/part-2/abc",
+					RuleIDs:      []string{"P1"},
+					Results:      []resultKey{{"P1", 0, "a1"}, {"P1", 0, "a3"}},
+				},
+			},
+			{
+				{
+					AutomationID: "analyze-code-security-scc/part-3/abc",
+					RuleIDs:      []string{"P1", "P2", "P3"},
+					Results:      []resultKey{{"P1", 0, "a2"}, {"P2", 1, "a2"}, {"P3", 2, "a1"}},
+				},
+			},
+		}
+
+		reports := [][]part{}
+		for _, report := range got {
+			parts := []part{}
+			for _, run := range report.Runs {
+				ruleIDs := []string{}
+				for _, rule := range run.Tool.Driver.Rules {
+					ruleIDs = append(ruleIDs, rule.ID)
+				}
+				parts = append(parts, part{AutomationID: run.AutomationDetails.ID, RuleIDs: ruleIDs, Results: resultKeys(run.Results)})
+			}
+			reports = append(reports, parts)
+		}
+		if diff := cmp.Diff(want, reports); diff != "" {
+			t.Errorf("Split() unexpected parts (-want, +got): %v", diff)
+		}
+	})
+
+	t.Run("StableParts", func(t *testing.T) {
+		partIDs := func(sarifReport templates.SarifOutput) ([]string, map[string]string) {
+			t.Helper()
+			got, err := Split(sarifReport, 100, 0, 3)
+			if err != nil {
+				t.Fatalf("Split() failed: %v", err)
+			}
+			ids := []string{}
+			partOfResult := make(map[string]string)
+			for _, report := range got {
+				for _, run := range report.Runs {
+					ids = append(ids, run.AutomationDetails.ID)
+					for _, result := range run.Results {
+						partOfResult[resultFingerprint(result)] = run.AutomationDetails.ID
+					}
+				}
+			}
+			return ids, partOfResult
+		}
+
+		// With one result less, the parts stay the same, even the one left
+		// without results, and no result moves to another part.
+		fewer := withRuns(sarifReport, []templates.Run{sarifReport.Runs[0]})
+		fewer.Runs[0].Results = sarifReport.Runs[0].Results[1:]
+
+		ids, parts := partIDs(sarifReport)
+		fewerIDs, fewerParts := partIDs(fewer)
+		if diff := cmp.Diff(ids, fewerIDs); diff != "" {
+			t.Errorf("Split() parts changed with one result less (-all, +fewer): %v", diff)
+		}
+		for fingerprint, part := range fewerParts {
+			if parts[fingerprint] != part {
+				t.Errorf("Split() moved result %s from %s to %s", fingerprint, parts[fingerprint], part)
+			}
+		}
+	})
+
+	t.Run("EmptyParts", func(t *testing.T) {
+		got, err := Split(sarifReport, 100, 0, 20)
+		if err != nil {
+			t.Fatalf("Split() failed: %v", err)
+		}
+		if len(got) != 1 || len(got[0].Runs) != 20 {
+			t.Fatalf("Split() returned %d reports, want 1 with 20 runs", len(got))
+		}
+
+		empty := 0
+		for _, run := range got[0].Runs {
+			if run.Results == nil {
+				t.Errorf("Split() part %s has nil results, want an empty list", run.AutomationDetails.ID)
+			}
+			if len(run.Results) == 0 {
+				empty++
+			}
+		}
+		if empty == 0 {
+			t.Errorf("Split() returned no empty parts, want 20 parts for 6 results")
+		}
+
+		sarifJSON, err := json.Marshal(got[0])
+		if err != nil {
+			t.Fatalf("json.Marshal() failed: %v", err)
+		}
+		if !strings.Contains(string(sarifJSON), `"results":[]`) {
+			t.Errorf("Split() empty parts are written without results, want an empty list")
+		}
+	})
+
+	t.Run("PartTooLarge", func(t *testing.T) {
+		if _, err := Split(sarifReport, 2, 0, 3); err == nil {
+			t.Errorf("Split() with a part over the limit succeeded, want error")
+		}
+	})
+
+	t.Run("PartProperties", func(t *testing.T) {
+		limited := LimitResults(sarifReport, 2, 0)
+		got, err := Split(limited, 3, 0, 3)
+		if err != nil {
+			t.Fatalf("Split() failed: %v", err)
+		}
+
+		want := []templates.RunProperties{
+			{
+				ReportName:      "organizations/1/locations/global/reports/abc",
+				SeverityCounts:  map[string]int{"CRITICAL": 1, "HIGH": 0, "MEDIUM": 0, "LOW": 1},
+				TotalViolations: 2,
+				DroppedResults:  1,
+			},
+			{
+				ReportName:      "organizations/1/locations/global/reports/abc",
+				SeverityCounts:  map[string]int{"CRITICAL": 0, "HIGH": 0, "MEDIUM": 0, "LOW": 1},
+				TotalViolations: 1,
+			},
+			{
+				ReportName:      "organizations/1/locations/global/reports/abc",
+				SeverityCounts:  map[string]int{"CRITICAL": 1, "HIGH": 1, "MEDIUM": 0, "LOW": 1},
+				TotalViolations: 3,
+			},
+		}
+		properties := []templates.RunProperties{}
+		for _, report := range got {
+			for _, run := range report.Runs {
+				properties = append(properties, run.Properties)
+			}
+		}
+		if diff := cmp.Diff(want, properties); diff != "" {
+			t.Errorf("Split() unexpected run properties (-want, +got): %v", diff)
+		}
+	})
+
+	t.Run("MaxSize", func(t *testing.T) {
+		size, err := compressedSize(sarifReport)
+		if err != nil {
+			t.Fatalf("compressedSize() failed: %v", err)
+		}
+
+		got, err := Split(sarifReport, 0, size-1, DEFAULT_PARTS_PER_RUN)
+		if err != nil {
+			t.Fatalf("Split() failed: %v", err)
+		}
+		if len(got) < 2 {
+			t.Fatalf("Split() returned %d reports, want at least 2", len(got))
+		}
+
+		results := 0
+		for i, report := range got {
+			reportSize, err := compressedSize(report)
+			if err != nil {
+				t.Fatalf("compressedSize() failed: %v", err)
+			}
+			if reportSize >= size {
+				t.Errorf("Split() report %d has %d bytes, want less than %d", i, reportSize, size)
+			}
+			for _, run := range report.Runs {
+				results += len(run.Results)
+			}
+		}
+		if results != len(sarifReport.Runs[0].Results) {
+			t.Errorf("Split() reports have %d results, want %d", results, len(sarifReport.Runs[0].Results))
+		}
+	})
+
+	t.Run("ResultTooLarge", func(t *testing.T) {
+		if _, err := Split(sarifReport, 0, 100, DEFAULT_PARTS_PER_RUN); err == nil {
+			t.Errorf("Split() with too small size succeeded, want error")
+		}
+	})
+
+	t.Run("RunsPerFile", func(t *testing.T) {
+		manyRuns := withRuns(sarifReport, nil)
+		for i := 0; i < GITHUB_MAX_RUNS_PER_FILE+1; i++ {
+			run := sarifReport.Runs[0]
+			run.AutomationDetails = &templates.AutomationDetails{ID: fmt.Sprintf("%s/root%d/abc", IAC_TOOL_NAME, i)}
+			manyRuns.Runs = append(manyRuns.Runs, run)
+		}
+
+		got, err := Split(manyRuns, 0, 0, DEFAULT_PARTS_PER_RUN)
+		if err != nil {
+			t.Fatalf("Split() failed: %v", err)
+		}
+		if len(got) != 2 || len(got[0].Runs) != GITHUB_MAX_RUNS_PER_FILE || len(got[1].Runs) != 1 {
+			t.Errorf("Split() returned %d reports, want %d and 1 runs", len(got), GITHUB_MAX_RUNS_PER_FILE)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// Merge combines SARIF reports of this tool into one. Runs with the same
+// automation ID are combined into a single run with the union of their rules
+// and taxonomies; all other runs are kept as they are. Runs without
+// automation details are never combined as they can't be told apart.
+//
+// It fails when runs that are kept apart share a category, e.g. the runs of
+// reports a and b converted without category, because code scanning replaces
+// one analysis of a category with the other. See Categorize.
+func Merge(sarifReports []templates.SarifOutput) (templates.SarifOutput, error) {
+	merged := templates.SarifOutput{
+		Version: SARIF_VERSION,
+		Schema:  SARIF_SCHEMA,
+		Runs:    []templates.Run{},
+	}
+	runIndexes := make(map[string]int)
+
+	for i, sarifReport := range sarifReports {
+		if sarifReport.Version != SARIF_VERSION {
+			return templates.SarifOutput{}, fmt.Errorf("report %d: unsupported SARIF version %q", i, sarifReport.Version)
+		}
+
+		for _, run := range sarifReport.Runs {
+			if run.Tool.Driver.Name != IAC_TOOL_NAME {
+				return templates.SarifOutput{}, fmt.Errorf("report %d: run of tool %q, want %q", i, run.Tool.Driver.Name, IAC_TOOL_NAME)
+			}
+
+			if run.AutomationDetails == nil {
+				merged.Runs = append(merged.Runs, run)
+				continue
+			}
+
+			id := run.AutomationDetails.ID
+			if j, ok := runIndexes[id]; ok {
+				merged.Runs[j] = mergeRuns(merged.Runs[j], run)
+				continue
+			}
+			runIndexes[id] = len(merged.Runs)
+			merged.Runs = append(merged.Runs, run)
+		}
+	}
+
+	if err := checkCategories(merged.Runs); err != nil {
+		return templates.SarifOutput{}, err
+	}
+
+	return merged, nil
+}
+
+// Categorize puts the runs of sarifReport without a category of their own
+// into category, as Options.Category does, e.g. analyze-code-security-scc/a
+// becomes analyze-code-security-scc/network/a. Runs that already have a
+// category are kept as they are.
+func Categorize(sarifReport templates.SarifOutput, category string) templates.SarifOutput {
+	if category == "" {
+		return sarifReport
+	}
+
+	runs := []templates.Run{}
+	for _, run := range sarifReport.Runs {
+		if runCategory(run) == IAC_TOOL_NAME {
+			reportID := ""
+			if run.AutomationDetails != nil {
+				reportID = strings.TrimPrefix(run.AutomationDetails.ID, IAC_TOOL_NAME+"/")
+			}
+			run.AutomationDetails = automationDetails(reportID, category)
+		}
+		runs = append(runs, run)
+	}
+	sarifReport.Runs = runs
+	return sarifReport
+}
+
+// checkCategories returns an error for the first two runs with the same
+// category.
+func checkCategories(runs []templates.Run) error {
+	ids := make(map[string]string)
+	for _, run := range runs {
+		id := "<none>"
+		if run.AutomationDetails != nil {
+			id = run.AutomationDetails.ID
+		}
+
+		category := runCategory(run)
+		if other, ok := ids[category]; ok {
+			return fmt.Errorf("runs %s and %s share the category %s, which code scanning can't tell apart: give the reports distinct categories", other, id, category)
+		}
+		ids[category] = id
+	}
+	return nil
+}
+
+// mergeRuns returns run a with the rules, results, taxonomies, invocations
+// and violation counts of run b added. Rules of b whose ID is already used in
+// a are dropped.
+func mergeRuns(a, b templates.Run) templates.Run {
+	ruleIDs := make(map[string]bool)
+	rules := []templates.Rule{}
+	for _, rule := range append(append([]templates.Rule{}, a.Tool.Driver.Rules...), b.Tool.Driver.Rules...) {
+		if !ruleIDs[rule.ID] {
+			ruleIDs[rule.ID] = true
+			rules = append(rules, rule)
+		}
+	}
+	sortRules(rules)
+
+	results := append(append([]templates.Result{}, a.Results...), b.Results...)
+	sortResults(results)
+	setRuleIndexes(rules, results)
+
+	a.Tool.Driver.Rules = rules
+	a.Results = results
+	a.Invocations = append(append([]templates.Invocation{}, a.Invocations...), b.Invocations...)
+	a.Taxonomies = mergeTaxonomies(a.Taxonomies, b.Taxonomies)
+
+	if len(b.OriginalURIBaseIDs) > 0 {
+		baseIDs := make(map[string]templates.ArtifactLocation)
+		for id, location := range b.OriginalURIBaseIDs {
+			baseIDs[id] = location
+		}
+		for id, location := range a.OriginalURIBaseIDs {
+			baseIDs[id] = location
+		}
+		a.OriginalURIBaseIDs = baseIDs
+	}
+
+	severityCounts := make(map[string]int)
+	for s, count := range a.Properties.SeverityCounts {
+		severityCounts[s] += count
+	}
+	for s, count := range b.Properties.SeverityCounts {
+		severityCounts[s] += count
+	}
+	a.Properties.SeverityCounts = severityCounts
+	a.Properties.TotalViolations += b.Properties.TotalViolations
+	a.Properties.DroppedResults += b.Properties.DroppedResults
+	if a.Properties.ReportName != b.Properties.ReportName {
+		a.Properties.ReportName = ""
+	}
+
+	return a
+}
+
+func mergeTaxonomies(a, b []templates.ToolComponent) []templates.ToolComponent {
+	taxaByName := make(map[string]map[string]bool)
+	for _, taxonomy := range append(append([]templates.ToolComponent{}, a...), b...) {
+		if taxaByName[taxonomy.Name] == nil {
+			taxaByName[taxonomy.Name] = make(map[string]bool)
+		}
+		for _, taxon := range taxonomy.Taxa {
+			taxaByName[taxonomy.Name][taxon.ID] = true
+		}
+	}
+
+	taxonomies := []templates.ToolComponent{}
+	for name, ids := range taxaByName {
+		taxa := []templates.Taxon{}
+		for id := range ids {
+			taxa = append(taxa, templates.Taxon{ID: id})
+		}
+		sort.Slice(taxa, func(i, j int) bool {
+			return taxa[i].ID < taxa[j].ID
+		})
+		taxonomies = append(taxonomies, templates.ToolComponent{Name: name, Taxa: taxa})
+	}
+	sort.Slice(taxonomies, func(i, j int) bool {
+		return taxonomies[i].Name < taxonomies[j].Name
+	})
+
+	return taxonomies
+}
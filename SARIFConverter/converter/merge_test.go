@@ -0,0 +1,182 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func convert(t *testing.T, name, category string, violations ...templates.Violation) templates.SarifOutput {
+	t.Helper()
+	response := templates.Responses{
+		Name:                name,
+		IacValidationReport: templates.IACValidationReport{Violations: violations},
+	}
+	sarifReport, err := FromIACScanReport(response, Options{Category: category})
+	if err != nil {
+		t.Fatalf("FromIACScanReport() failed: %v", err)
+	}
+	return sarifReport
+}
+
+func TestMerge(t *testing.T) {
+	network := convert(t, "reports/a", "network",
+		templates.Violation{PolicyID: "P2", AssetID: "vpc", Severity: "HIGH", ViolatedPolicy: templates.PolicyDetails{ComplianceStandards: []string{"CIS 2.0 3.1"}}})
+	app := convert(t, "reports/b", "app",
+		templates.Violation{PolicyID: "P1", AssetID: "bucket", Severity: "LOW"})
+	networkAgain := convert(t, "reports/a", "network",
+		templates.Violation{PolicyID: "P1", AssetID: "subnet", Severity: "LOW"},
+		templates.Violation{PolicyID: "P2", AssetID: "vpc2", Severity: "HIGH", ViolatedPolicy: templates.PolicyDetails{ComplianceStandards: []string{"CIS 2.0 3.2"}}})
+	unnamed := convert(t, "", "", templates.Violation{PolicyID: "P3", AssetID: "x", Severity: "LOW"})
+
+	merged, err := Merge([]templates.SarifOutput{network, app, networkAgain, unnamed})
+	if err != nil {
+		t.Fatalf("Merge() failed: %v", err)
+	}
+
+	type runSummary struct {
+		ID         string
+		Rules      []string
+		Results    []string
+		Taxonomies []templates.ToolComponent
+		Total      int
+	}
+	got := []runSummary{}
+	for _, run := range merged.Runs {
+		summary := runSummary{Taxonomies: run.Taxonomies, Total: run.Properties.TotalViolations}
+		if run.AutomationDetails != nil {
+			summary.ID = run.AutomationDetails.ID
+		}
+		for _, rule := range run.Tool.Driver.Rules {
+			summary.Rules = append(summary.Rules, rule.ID)
+		}
+		for _, result := range run.Results {
+			summary.Results = append(summary.Results, result.RuleID+"/"+result.Properties.AssetID)
+			if rule := run.Tool.Driver.Rules[result.RuleIndex]; rule.ID != result.RuleID {
+				t.Errorf("result %s has ruleIndex %d pointing at rule %s", result.RuleID, result.RuleIndex, rule.ID)
+			}
+		}
+		got = append(got, summary)
+	}
+
+	want := []runSummary{
+		{
+			ID:         "analyze-code-security-scc/network/a",
+			Rules:      []string{"P1", "P2"},
+			Results:    []string{"P1/subnet", "P2/vpc", "P2/vpc2"},
+			Taxonomies: []templates.ToolComponent{{Name: "CIS 2.0", Taxa: []templates.Taxon{{ID: "3.1"}, {ID: "3.2"}}}},
+			Total:      3,
+		},
+		{
+			ID:         "analyze-code-security-scc/app/b",
+			Rules:      []string{"P1"},
+			Results:    []string{"P1/bucket"},
+			Taxonomies: []templates.ToolComponent{},
+			Total:      1,
+		},
+		{Rules: []string{"P3"}, Results: []string{"P3/x"}, Taxonomies: []templates.ToolComponent{}, Total: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Merge() unexpected runs (-want, +got): %v", diff)
+	}
+}
+
+func TestMerge_SharedCategory(t *testing.T) {
+	a := convert(t, "reports/a", "", templates.Violation{PolicyID: "P1", AssetID: "x", Severity: "LOW"})
+	b := convert(t, "reports/b", "", templates.Violation{PolicyID: "P1", AssetID: "y", Severity: "LOW"})
+	unnamed := convert(t, "", "", templates.Violation{PolicyID: "P1", AssetID: "z", Severity: "LOW"})
+	network := convert(t, "reports/a", "network", templates.Violation{PolicyID: "P1", AssetID: "x", Severity: "LOW"})
+	networkB := convert(t, "reports/b", "network", templates.Violation{PolicyID: "P1", AssetID: "y", Severity: "LOW"})
+
+	tests := []struct {
+		name        string
+		sarifReport []templates.SarifOutput
+		wantErr     bool
+	}{
+		{name: "ReportsWithoutCategory_Failure", sarifReport: []templates.SarifOutput{a, b}, wantErr: true},
+		{name: "UnnamedReports_Failure", sarifReport: []templates.SarifOutput{unnamed, unnamed}, wantErr: true},
+		{name: "ReportsOfOneCategory_Failure", sarifReport: []templates.SarifOutput{network, networkB}, wantErr: true},
+		{name: "SameReport_Succeeds", sarifReport: []templates.SarifOutput{a, a}},
+		{name: "Categorized_Succeeds", sarifReport: []templates.SarifOutput{Categorize(a, "app"), Categorize(b, "db")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Merge(test.sarifReport)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Merge() error = %v, want error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		name        string
+		sarifReport templates.SarifOutput
+		category    string
+		want        *templates.AutomationDetails
+	}{
+		{
+			name:        "WithoutCategory",
+			sarifReport: convert(t, "reports/a", ""),
+			category:    "app",
+			want:        &templates.AutomationDetails{ID: "analyze-code-security-scc/app/a"},
+		},
+		{
+			name:        "Unnamed",
+			sarifReport: convert(t, "", ""),
+			category:    "app",
+			want:        &templates.AutomationDetails{ID: "analyze-code-security-scc/app/"},
+		},
+		{
+			name:        "CategoryKept",
+			sarifReport: convert(t, "reports/a", "network"),
+			category:    "app",
+			want:        &templates.AutomationDetails{ID: "analyze-code-security-scc/network/a"},
+		},
+		{
+			name:        "NoCategoryGiven",
+			sarifReport: convert(t, "reports/a", ""),
+			want:        &templates.AutomationDetails{ID: "analyze-code-security-scc/a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Categorize(test.sarifReport, test.category)
+			if diff := cmp.Diff(test.want, got.Runs[0].AutomationDetails); diff != "" {
+				t.Errorf("Categorize() unexpected automation details (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestMerge_OtherTool(t *testing.T) {
+	other := templates.SarifOutput{
+		Version: SARIF_VERSION,
+		Runs:    []templates.Run{{Tool: templates.Tool{Driver: templates.Driver{Name: "other"}}}},
+	}
+
+	if _, err := Merge([]templates.SarifOutput{other}); err == nil {
+		t.Errorf("Merge() succeeded for a run of another tool, want error")
+	}
+}
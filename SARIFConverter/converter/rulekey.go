@@ -0,0 +1,156 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// Rule keys, see Options.RuleKey.
+const (
+	// RULE_KEY_POLICY makes one rule per policy ID.
+	RULE_KEY_POLICY = "policy"
+	// RULE_KEY_POLICY_REVISION makes one rule per policy ID and posture
+	// revision, with IDs like P1@r1.
+	RULE_KEY_POLICY_REVISION = "policy_revision"
+)
+
+// RULE_ID_REVISION_SEPARATOR separates the policy ID from the posture revision
+// ID in rule IDs of RULE_KEY_POLICY_REVISION.
+const RULE_ID_REVISION_SEPARATOR = "@"
+
+// ParseRuleKey checks that key is one of RULE_KEY_POLICY and
+// RULE_KEY_POLICY_REVISION, an empty key meaning RULE_KEY_POLICY.
+func ParseRuleKey(key string) (string, error) {
+	switch key = strings.ToLower(strings.TrimSpace(key)); key {
+	case "", RULE_KEY_POLICY:
+		return RULE_KEY_POLICY, nil
+	case RULE_KEY_POLICY_REVISION:
+		return RULE_KEY_POLICY_REVISION, nil
+	default:
+		return "", fmt.Errorf("invalid rule key: %s", key)
+	}
+}
+
+// ruleID returns the ID of the rule of violation. Violations without posture
+// revision use the policy ID with every key.
+func ruleID(violation templates.Violation, key string) string {
+	revision := violation.ViolatedPosture.PostureRevisionID
+	if key != RULE_KEY_POLICY_REVISION || revision == "" {
+		return violation.PolicyID
+	}
+	return violation.PolicyID + RULE_ID_REVISION_SEPARATOR + revision
+}
+
+// Conflict is rule metadata that differs between violations of the same rule.
+type Conflict struct {
+	RuleID string
+	Field  string
+	// Values are the distinct values in the order of the report, the first
+	// being the one the rule uses.
+	Values []string
+}
+
+func (c Conflict) String() string {
+	quoted := []string{}
+	for _, value := range c.Values {
+		quoted = append(quoted, fmt.Sprintf("%q", value))
+	}
+	return fmt.Sprintf("Rule %s has conflicting %s: %s; using %s.", c.RuleID, c.Field, strings.Join(quoted, ", "), quoted[0])
+}
+
+// ConflictError is returned by FromIACScanReport with Options.FailOnConflict
+// when violations of the same rule disagree on its metadata.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	messages := []string{}
+	for _, c := range e.Conflicts {
+		messages = append(messages, c.String())
+	}
+	return fmt.Sprintf("conflicting rule metadata: %s", strings.Join(messages, " "))
+}
+
+// ruleFields are the violation fields that end up in the rule metadata and
+// must agree between violations of the same rule. Next steps and posture
+// deployments are expected to differ.
+var ruleFields = []struct {
+	name  string
+	value func(templates.Violation) string
+}{
+	{"severity", func(v templates.Violation) string { return v.Severity }},
+	{"description", func(v templates.Violation) string { return v.ViolatedPolicy.Description }},
+	{"constraint type", func(v templates.Violation) string { return v.ViolatedPolicy.ConstraintType }},
+	{"constraint", func(v templates.Violation) string { return v.ViolatedPolicy.Constraint }},
+	{"compliance standards", func(v templates.Violation) string { return strings.Join(v.ViolatedPolicy.ComplianceStandards, ", ") }},
+	{"posture", func(v templates.Violation) string { return v.ViolatedPosture.Posture }},
+	{"posture revision", func(v templates.Violation) string { return v.ViolatedPosture.PostureRevisionID }},
+	{"policy set", func(v templates.Violation) string { return v.ViolatedPosture.PolicySet }},
+}
+
+// findConflicts returns the conflicts between violations of the same rule,
+// sorted by rule ID and in the order of ruleFields.
+func findConflicts(violations []templates.Violation, key string) []Conflict {
+	violationsByRule := make(map[string][]templates.Violation)
+	for _, violation := range violations {
+		id := ruleID(violation, key)
+		violationsByRule[id] = append(violationsByRule[id], violation)
+	}
+
+	ids := []string{}
+	for id := range violationsByRule {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	conflicts := []Conflict{}
+	for _, id := range ids {
+		for _, field := range ruleFields {
+			seen := make(map[string]bool)
+			values := []string{}
+			for _, violation := range violationsByRule[id] {
+				if value := field.value(violation); !seen[value] {
+					seen[value] = true
+					values = append(values, value)
+				}
+			}
+			if len(values) > 1 {
+				conflicts = append(conflicts, Conflict{RuleID: id, Field: field.name, Values: values})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// conflictNotifications returns a warning notification per conflict.
+func conflictNotifications(conflicts []Conflict) []templates.Notification {
+	notifications := []templates.Notification{}
+	for _, c := range conflicts {
+		notifications = append(notifications, templates.Notification{
+			Level:   LEVEL_WARNING,
+			Message: templates.Message{Text: c.String()},
+		})
+	}
+	return notifications
+}
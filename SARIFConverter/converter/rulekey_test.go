@@ -0,0 +1,165 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestParseRuleKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "", want: RULE_KEY_POLICY},
+		{key: "policy", want: RULE_KEY_POLICY},
+		{key: " Policy_Revision ", want: RULE_KEY_POLICY_REVISION},
+		{key: "revision", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseRuleKey(test.key)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseRuleKey(%q) error = %v, want error: %v", test.key, err, test.wantErr)
+		}
+		if got != test.want {
+			t.Errorf("ParseRuleKey(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestFromIACScanReport_RuleKey(t *testing.T) {
+	response := templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				postureViolation("P1", "postures/p", "r1", "set"),
+				postureViolation("P1", "postures/p", "r2", "set"),
+				{PolicyID: "P1", AssetID: "asset2", Severity: "LOW", ViolatedPosture: templates.PostureDetails{Posture: "postures/p", PostureRevisionID: "r2", PolicySet: "set"}},
+				postureViolation("P2", "", "", ""),
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		ruleKey           string
+		wantRules         []string
+		wantResults       []string
+		wantNotifications []string
+	}{
+		{
+			name:        "Policy",
+			ruleKey:     RULE_KEY_POLICY,
+			wantRules:   []string{"P1", "P2"},
+			wantResults: []string{"P1", "P1", "P1", "P2"},
+			wantNotifications: []string{
+				`Rule P1 has conflicting severity: "HIGH", "LOW"; using "HIGH".`,
+				`Rule P1 has conflicting posture revision: "r1", "r2"; using "r1".`,
+			},
+		},
+		{
+			name:        "PolicyRevision",
+			ruleKey:     RULE_KEY_POLICY_REVISION,
+			wantRules:   []string{"P1@r1", "P1@r2", "P2"},
+			wantResults: []string{"P1@r1", "P1@r2", "P1@r2", "P2"},
+			wantNotifications: []string{
+				`Rule P1@r2 has conflicting severity: "HIGH", "LOW"; using "HIGH".`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := FromIACScanReport(response, Options{RuleKey: test.ruleKey})
+			if err != nil {
+				t.Fatalf("FromIACScanReport() failed: %v", err)
+			}
+			run := got.Runs[0]
+
+			rules := []string{}
+			for _, rule := range run.Tool.Driver.Rules {
+				rules = append(rules, rule.ID)
+				if rule.Name != rule.ID[:2] {
+					t.Errorf("FromIACScanReport() rule %s has name %q, want the policy ID", rule.ID, rule.Name)
+				}
+			}
+			if diff := cmp.Diff(test.wantRules, rules); diff != "" {
+				t.Errorf("FromIACScanReport() unexpected rules (-want, +got): %v", diff)
+			}
+
+			fingerprints := make(map[string]bool)
+			for _, result := range run.Results {
+				fingerprint := result.Fingerprints[FINGERPRINT_KEY]
+				if test.ruleKey == RULE_KEY_POLICY_REVISION && fingerprints[fingerprint] {
+					t.Errorf("FromIACScanReport() results of rule %s share the fingerprint %s", result.RuleID, fingerprint)
+				}
+				fingerprints[fingerprint] = true
+			}
+
+			results := []string{}
+			for _, result := range run.Results {
+				results = append(results, result.RuleID)
+				if run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+					t.Errorf("FromIACScanReport() result of rule %s has ruleIndex %d", result.RuleID, result.RuleIndex)
+				}
+			}
+			if diff := cmp.Diff(test.wantResults, results); diff != "" {
+				t.Errorf("FromIACScanReport() unexpected results (-want, +got): %v", diff)
+			}
+
+			notifications := []string{}
+			for _, notification := range run.Invocations[0].ToolExecutionNotifications {
+				notifications = append(notifications, notification.Message.Text)
+			}
+			if diff := cmp.Diff(test.wantNotifications, notifications); diff != "" {
+				t.Errorf("FromIACScanReport() unexpected notifications (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestFromIACScanReport_FailOnConflict(t *testing.T) {
+	response := templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				{PolicyID: "P1", AssetID: "a1", Severity: "HIGH", ViolatedPolicy: templates.PolicyDetails{Description: "old"}},
+				{PolicyID: "P1", AssetID: "a2", Severity: "HIGH", ViolatedPolicy: templates.PolicyDetails{Description: "new"}},
+			},
+		},
+	}
+
+	_, err := FromIACScanReport(response, Options{FailOnConflict: true})
+	var conflictError *ConflictError
+	if !errors.As(err, &conflictError) {
+		t.Fatalf("FromIACScanReport() error = %v, want *ConflictError", err)
+	}
+	want := []Conflict{{RuleID: "P1", Field: "description", Values: []string{"old", "new"}}}
+	if diff := cmp.Diff(want, conflictError.Conflicts); diff != "" {
+		t.Errorf("FromIACScanReport() unexpected conflicts (-want, +got): %v", diff)
+	}
+
+	response.IacValidationReport.Violations[1].ViolatedPolicy.Description = "old"
+	if _, err := FromIACScanReport(response, Options{FailOnConflict: true}); err != nil {
+		t.Errorf("FromIACScanReport() without conflicts failed: %v", err)
+	}
+}
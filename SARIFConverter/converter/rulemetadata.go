@@ -0,0 +1,153 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// Constraint types of the violated policies.
+const (
+	CONSTRAINT_TYPE_ORG_POLICY           = "ORG_POLICY"
+	CONSTRAINT_TYPE_ORG_POLICY_CUSTOM    = "ORG_POLICY_CUSTOM"
+	CONSTRAINT_TYPE_SHA_MODULE           = "SECURITY_HEALTH_ANALYTICS_MODULE"
+	CONSTRAINT_TYPE_SHA_CUSTOM_MODULE    = "SECURITY_HEALTH_ANALYTICS_CUSTOM_MODULE"
+	ORG_POLICY_DOCUMENTATION_LINK        = "https://cloud.google.com/resource-manager/docs/organization-policy/org-policy-constraints"
+	ORG_POLICY_CUSTOM_DOCUMENTATION_LINK = "https://cloud.google.com/resource-manager/docs/organization-policy/creating-managing-custom-constraints"
+	SHA_MODULE_DOCUMENTATION_LINK        = "https://cloud.google.com/security-command-center/docs/concepts-vulnerabilities-findings"
+	SHA_CUSTOM_MODULE_DOCUMENTATION_LINK = "https://cloud.google.com/security-command-center/docs/custom-modules-sha-overview"
+)
+
+type constraintKind struct {
+	label   string
+	helpURI string
+}
+
+var constraintKinds = map[string]constraintKind{
+	CONSTRAINT_TYPE_ORG_POLICY:        {label: "Organization policy constraint", helpURI: ORG_POLICY_DOCUMENTATION_LINK},
+	CONSTRAINT_TYPE_ORG_POLICY_CUSTOM: {label: "Custom organization policy constraint", helpURI: ORG_POLICY_CUSTOM_DOCUMENTATION_LINK},
+	CONSTRAINT_TYPE_SHA_MODULE:        {label: "Security Health Analytics detector", helpURI: SHA_MODULE_DOCUMENTATION_LINK},
+	CONSTRAINT_TYPE_SHA_CUSTOM_MODULE: {label: "Security Health Analytics custom module", helpURI: SHA_CUSTOM_MODULE_DOCUMENTATION_LINK},
+}
+
+// constraint holds the fields of PolicyDetails.Constraint that name the
+// constraint, for each of the constraint types.
+type constraint struct {
+	OrgPolicyConstraint struct {
+		CannedConstraintID string `json:"cannedConstraintId"`
+	} `json:"orgPolicyConstraint"`
+	OrgPolicyConstraintCustom struct {
+		CustomConstraint struct {
+			Name string `json:"name"`
+		} `json:"customConstraint"`
+	} `json:"orgPolicyConstraintCustom"`
+	SecurityHealthAnalyticsModule struct {
+		ModuleName string `json:"moduleName"`
+	} `json:"securityHealthAnalyticsModule"`
+	SecurityHealthAnalyticsCustomModule struct {
+		DisplayName string `json:"displayName"`
+	} `json:"securityHealthAnalyticsCustomModule"`
+}
+
+// constraintName returns the ID of the canned or custom constraint or module
+// described by policy, or "" when the constraint can't be parsed.
+func constraintName(policy templates.PolicyDetails) string {
+	var c constraint
+	if err := json.Unmarshal([]byte(policy.Constraint), &c); err != nil {
+		return ""
+	}
+
+	for _, name := range []string{
+		c.OrgPolicyConstraint.CannedConstraintID,
+		path.Base(c.OrgPolicyConstraintCustom.CustomConstraint.Name),
+		c.SecurityHealthAnalyticsModule.ModuleName,
+		c.SecurityHealthAnalyticsCustomModule.DisplayName,
+	} {
+		if name != "" && name != "." {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// ruleName returns the constraint name, falling back to the policy ID.
+func ruleName(policyID string, policy templates.PolicyDetails) string {
+	if name := constraintName(policy); name != "" {
+		return name
+	}
+	return policyID
+}
+
+// shortDescription names the violated constraint, e.g. "Organization policy
+// constraint storage.uniformBucketLevelAccess".
+func shortDescription(policyID string, policy templates.PolicyDetails) string {
+	name := constraintName(policy)
+	kind, known := constraintKinds[policy.ConstraintType]
+
+	switch {
+	case name != "" && known:
+		return fmt.Sprintf("%s %s", kind.label, name)
+	case name != "":
+		return name
+	case policy.Description != "":
+		return policy.Description
+	default:
+		return policyID
+	}
+}
+
+// helpURI returns the documentation of the constraint type, or "" for
+// unknown types.
+func helpURI(policy templates.PolicyDetails) string {
+	return constraintKinds[policy.ConstraintType].helpURI
+}
+
+// help lists the next steps and compliance standards of violation, or returns
+// nil when it has neither.
+func help(violation templates.Violation, uri string) *templates.Help {
+	standards := violation.ViolatedPolicy.ComplianceStandards
+	if violation.NextSteps == "" && len(standards) == 0 {
+		return nil
+	}
+
+	var text, markdown strings.Builder
+	if violation.NextSteps != "" {
+		fmt.Fprintf(&text, "Next steps: %s\n", violation.NextSteps)
+		fmt.Fprintf(&markdown, "**Next steps:** %s\n", violation.NextSteps)
+	}
+	if len(standards) > 0 {
+		fmt.Fprintf(&text, "Compliance standards: %s\n", strings.Join(standards, ", "))
+		markdown.WriteString("\n**Compliance standards:**\n\n")
+		for _, standard := range standards {
+			fmt.Fprintf(&markdown, "- %s\n", standard)
+		}
+	}
+	if uri != "" {
+		fmt.Fprintf(&markdown, "\n[Documentation](%s)\n", uri)
+	}
+
+	return &templates.Help{
+		Text:     strings.TrimSpace(text.String()),
+		Markdown: strings.TrimSpace(markdown.String()),
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestRuleMetadata(t *testing.T) {
+	tests := []struct {
+		name                 string
+		policy               templates.PolicyDetails
+		wantName             string
+		wantShortDescription string
+		wantHelpURI          string
+	}{
+		{
+			name: "OrgPolicy",
+			policy: templates.PolicyDetails{
+				ConstraintType: "ORG_POLICY",
+				Constraint:     `{"orgPolicyConstraint":{"cannedConstraintId":"storage.uniformBucketLevelAccess"}}`,
+			},
+			wantName:             "storage.uniformBucketLevelAccess",
+			wantShortDescription: "Organization policy constraint storage.uniformBucketLevelAccess",
+			wantHelpURI:          ORG_POLICY_DOCUMENTATION_LINK,
+		},
+		{
+			name: "CustomOrgPolicy",
+			policy: templates.PolicyDetails{
+				ConstraintType: "ORG_POLICY_CUSTOM",
+				Constraint:     `{"orgPolicyConstraintCustom":{"customConstraint":{"name":"organizations/123/customConstraints/custom.denyPublicIp"}}}`,
+			},
+			wantName:             "custom.denyPublicIp",
+			wantShortDescription: "Custom organization policy constraint custom.denyPublicIp",
+			wantHelpURI:          ORG_POLICY_CUSTOM_DOCUMENTATION_LINK,
+		},
+		{
+			name: "SecurityHealthAnalyticsModule",
+			policy: templates.PolicyDetails{
+				ConstraintType: "SECURITY_HEALTH_ANALYTICS_MODULE",
+				Constraint:     `{"securityHealthAnalyticsModule":{"moduleName":"BUCKET_POLICY_ONLY_DISABLED"}}`,
+			},
+			wantName:             "BUCKET_POLICY_ONLY_DISABLED",
+			wantShortDescription: "Security Health Analytics detector BUCKET_POLICY_ONLY_DISABLED",
+			wantHelpURI:          SHA_MODULE_DOCUMENTATION_LINK,
+		},
+		{
+			name: "SecurityHealthAnalyticsCustomModule",
+			policy: templates.PolicyDetails{
+				ConstraintType: "SECURITY_HEALTH_ANALYTICS_CUSTOM_MODULE",
+				Constraint:     `{"securityHealthAnalyticsCustomModule":{"displayName":"no_public_buckets"}}`,
+			},
+			wantName:             "no_public_buckets",
+			wantShortDescription: "Security Health Analytics custom module no_public_buckets",
+			wantHelpURI:          SHA_CUSTOM_MODULE_DOCUMENTATION_LINK,
+		},
+		{
+			name:                 "UnparsableConstraint_FallsBackToDescription",
+			policy:               templates.PolicyDetails{ConstraintType: "ORG_POLICY", Constraint: "not json", Description: "Buckets must not be public"},
+			wantName:             "P1",
+			wantShortDescription: "Buckets must not be public",
+			wantHelpURI:          ORG_POLICY_DOCUMENTATION_LINK,
+		},
+		{
+			name:                 "UnknownType_FallsBackToPolicyID",
+			policy:               templates.PolicyDetails{ConstraintType: "OTHER"},
+			wantName:             "P1",
+			wantShortDescription: "P1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ruleName("P1", test.policy); got != test.wantName {
+				t.Errorf("ruleName() = %q, want %q", got, test.wantName)
+			}
+			if got := shortDescription("P1", test.policy); got != test.wantShortDescription {
+				t.Errorf("shortDescription() = %q, want %q", got, test.wantShortDescription)
+			}
+			if got := helpURI(test.policy); got != test.wantHelpURI {
+				t.Errorf("helpURI() = %q, want %q", got, test.wantHelpURI)
+			}
+		})
+	}
+}
+
+func TestHelp(t *testing.T) {
+	violation := templates.Violation{
+		NextSteps:      "Enable uniform bucket-level access.",
+		ViolatedPolicy: templates.PolicyDetails{ComplianceStandards: []string{"CIS 2.0 5.2", "NIST 800-53 AC-3"}},
+	}
+
+	want := &templates.Help{
+		Text:     "Next steps: Enable uniform bucket-level access.\nCompliance standards: CIS 2.0 5.2, NIST 800-53 AC-3",
+		Markdown: "**Next steps:** Enable uniform bucket-level access.\n\n**Compliance standards:**\n\n- CIS 2.0 5.2\n- NIST 800-53 AC-3\n\n[Documentation](https://example.com)",
+	}
+	if diff := cmp.Diff(want, help(violation, "https://example.com")); diff != "" {
+		t.Errorf("help() unexpected result (-want, +got): %v", diff)
+	}
+
+	if got := help(templates.Violation{}, "https://example.com"); got != nil {
+		t.Errorf("help() = %v for a violation without next steps and standards, want nil", got)
+	}
+}
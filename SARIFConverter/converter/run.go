@@ -0,0 +1,101 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"path"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// NOTE_LEVEL is the level of the tool notification carrying the note of the
+// report.
+const NOTE_LEVEL = "note"
+
+// constructInvocations describes the scan that produced response. The loader
+// rejects failed operations, so the scan is always reported as successful.
+func constructInvocations(response templates.Responses) []templates.Invocation {
+	invocation := templates.Invocation{
+		ExecutionSuccessful: true,
+		StartTimeUTC:        response.CreateTime,
+		EndTimeUTC:          response.UpdateTime,
+	}
+
+	if note := response.IacValidationReport.Note; note != "" {
+		invocation.ToolExecutionNotifications = []templates.Notification{
+			{Level: NOTE_LEVEL, Message: templates.Message{Text: note}},
+		}
+	}
+
+	return []templates.Invocation{invocation}
+}
+
+// automationDetails identifies the run by the ID of the report, e.g.
+// analyze-code-security-scc/abc for organizations/1/locations/global/reports/abc.
+// Code scanning uses the part before the last slash as the category of the
+// analysis, so a category, when given, is inserted before the report ID.
+// Reports without a name, such as merged lists, only have a category if any.
+func automationDetails(reportName, category string) *templates.AutomationDetails {
+	prefix := IAC_TOOL_NAME + "/"
+	if category != "" {
+		prefix += category + "/"
+	}
+
+	switch {
+	case reportName != "":
+		return &templates.AutomationDetails{ID: prefix + path.Base(reportName)}
+	case category != "":
+		return &templates.AutomationDetails{ID: prefix}
+	default:
+		return nil
+	}
+}
+
+// runCategory returns the category of run, the part of its automation ID
+// before the last slash. Runs without automation details have the category
+// of the tool.
+func runCategory(run templates.Run) string {
+	if run.AutomationDetails == nil {
+		return IAC_TOOL_NAME
+	}
+
+	id := run.AutomationDetails.ID
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		return id[:i]
+	}
+	return id
+}
+
+// runProperties counts the violations per severity. UNKNOWN is only counted
+// when present, like in the other output formats.
+func runProperties(reportName string, violations []templates.Violation) templates.RunProperties {
+	severityCounts := make(map[string]int)
+	for _, s := range severity.Known {
+		severityCounts[s] = 0
+	}
+	for _, violation := range violations {
+		severityCounts[violation.Severity]++
+	}
+
+	return templates.RunProperties{
+		ReportName:      reportName,
+		SeverityCounts:  severityCounts,
+		TotalViolations: len(violations),
+	}
+}
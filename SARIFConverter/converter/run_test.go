@@ -0,0 +1,122 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestRunMetadata(t *testing.T) {
+	response := templates.Responses{
+		Name:       "organizations/123/locations/global/reports/abc",
+		CreateTime: "2024-05-01T10:00:00Z",
+		UpdateTime: "2024-05-01T10:02:30Z",
+		IacValidationReport: templates.IACValidationReport{
+			Note: "2 resources were not evaluated.",
+			Violations: []templates.Violation{
+				{PolicyID: "P1", AssetID: "a1", Severity: "HIGH"},
+				{PolicyID: "P1", AssetID: "a2", Severity: "high"},
+				{PolicyID: "P2", AssetID: "a1", Severity: "SEVERITY_UNSPECIFIED"},
+			},
+		},
+	}
+
+	output, err := FromIACScanReport(response, Options{SeverityPolicy: severity.Policy{Mode: severity.MODE_UNKNOWN}})
+	if err != nil {
+		t.Fatalf("FromIACScanReport() failed: %v", err)
+	}
+	run := output.Runs[0]
+
+	wantInvocations := []templates.Invocation{
+		{
+			ExecutionSuccessful: true,
+			StartTimeUTC:        "2024-05-01T10:00:00Z",
+			EndTimeUTC:          "2024-05-01T10:02:30Z",
+			ToolExecutionNotifications: []templates.Notification{
+				{Level: "note", Message: templates.Message{Text: "2 resources were not evaluated."}},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantInvocations, run.Invocations); diff != "" {
+		t.Errorf("Unexpected invocations (-want, +got): %v", diff)
+	}
+
+	wantAutomationDetails := &templates.AutomationDetails{ID: "analyze-code-security-scc/abc"}
+	if diff := cmp.Diff(wantAutomationDetails, run.AutomationDetails); diff != "" {
+		t.Errorf("Unexpected automationDetails (-want, +got): %v", diff)
+	}
+
+	wantProperties := templates.RunProperties{
+		ReportName:      "organizations/123/locations/global/reports/abc",
+		SeverityCounts:  map[string]int{"CRITICAL": 0, "HIGH": 2, "MEDIUM": 0, "LOW": 0, "UNKNOWN": 1},
+		TotalViolations: 3,
+	}
+	if diff := cmp.Diff(wantProperties, run.Properties); diff != "" {
+		t.Errorf("Unexpected properties (-want, +got): %v", diff)
+	}
+}
+
+func TestAutomationDetails(t *testing.T) {
+	tests := []struct {
+		name         string
+		reportName   string
+		category     string
+		want         *templates.AutomationDetails
+		wantCategory string
+	}{
+		{
+			name:         "ReportName",
+			reportName:   "organizations/1/locations/global/reports/abc",
+			want:         &templates.AutomationDetails{ID: "analyze-code-security-scc/abc"},
+			wantCategory: "analyze-code-security-scc",
+		},
+		{
+			name:         "ReportNameAndCategory",
+			reportName:   "organizations/1/locations/global/reports/abc",
+			category:     "network",
+			want:         &templates.AutomationDetails{ID: "analyze-code-security-scc/network/abc"},
+			wantCategory: "analyze-code-security-scc/network",
+		},
+		{
+			name:         "CategoryOnly",
+			category:     "network",
+			want:         &templates.AutomationDetails{ID: "analyze-code-security-scc/network/"},
+			wantCategory: "analyze-code-security-scc/network",
+		},
+		{
+			name:         "Neither",
+			wantCategory: "analyze-code-security-scc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := automationDetails(test.reportName, test.category)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("automationDetails() unexpected result (-want, +got): %v", diff)
+			}
+			if category := runCategory(templates.Run{AutomationDetails: got}); category != test.wantCategory {
+				t.Errorf("runCategory() = %q, want %q", category, test.wantCategory)
+			}
+		})
+	}
+}
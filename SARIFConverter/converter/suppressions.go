@@ -0,0 +1,148 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/gcp-scc-iac-validation-utils/loader"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+const (
+	SUPPRESSION_KIND_EXTERNAL   = "external"
+	SUPPRESSION_STATUS_ACCEPTED = "accepted"
+	EXPIRES_DATE_LAYOUT         = "2006-01-02"
+)
+
+// Waiver accepts the risk of the violations it matches. A violation matches
+// when it matches all of PolicyID, AssetID and Fingerprint that are set.
+// AssetID can be a path.Match pattern, where * doesn't match /, e.g.
+// "//storage.googleapis.com/projects/_/buckets/public-*".
+type Waiver struct {
+	PolicyID      string `json:"policyId"`
+	AssetID       string `json:"assetId"`
+	Fingerprint   string `json:"fingerprint"`
+	Justification string `json:"justification"`
+	Owner         string `json:"owner"`
+	// Expires is a date, e.g. 2025-01-31, or an RFC 3339 timestamp. The waiver
+	// no longer applies from then on.
+	Expires string `json:"expires"`
+
+	expiresAt time.Time
+}
+
+// ReadWaivers reads a list of waivers in JSON or YAML, optionally gzip
+// compressed, see loader.ReadInput. Unknown fields are rejected, as a
+// misspelled matcher would widen the waiver to every violation.
+func ReadWaivers(filePath string) ([]Waiver, error) {
+	data, err := loader.ReadInput(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("loader.ReadInput: %v", err)
+	}
+
+	var waivers []Waiver
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&waivers); err != nil {
+		return nil, fmt.Errorf("decoder.Decode: %v", err)
+	}
+
+	for i := range waivers {
+		if err := waivers[i].init(); err != nil {
+			return nil, fmt.Errorf("waiver %d: %v", i, err)
+		}
+	}
+
+	return waivers, nil
+}
+
+func (w *Waiver) init() error {
+	if w.PolicyID == "" && w.AssetID == "" && w.Fingerprint == "" {
+		return fmt.Errorf("one of policyId, assetId or fingerprint is required")
+	}
+	if w.Justification == "" {
+		return fmt.Errorf("justification is required")
+	}
+	if _, err := path.Match(w.AssetID, ""); err != nil {
+		return fmt.Errorf("invalid assetId pattern %q: %v", w.AssetID, err)
+	}
+	if w.Expires == "" {
+		return nil
+	}
+
+	expiresAt, err := time.Parse(EXPIRES_DATE_LAYOUT, w.Expires)
+	if err != nil {
+		expiresAt, err = time.Parse(time.RFC3339, w.Expires)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid expires %q, want a date or an RFC 3339 timestamp", w.Expires)
+	}
+	w.expiresAt = expiresAt
+
+	return nil
+}
+
+func (w Waiver) matches(violation templates.Violation, fingerprint string, now time.Time) bool {
+	if !w.expiresAt.IsZero() && !now.Before(w.expiresAt) {
+		return false
+	}
+	if w.PolicyID != "" && w.PolicyID != violation.PolicyID {
+		return false
+	}
+	if w.Fingerprint != "" && w.Fingerprint != fingerprint {
+		return false
+	}
+	if w.AssetID != "" {
+		if ok, _ := path.Match(w.AssetID, violation.AssetID); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// suppressions returns the suppressions of the first waiver matching
+// violation, or nil when the violation isn't waived.
+func suppressions(violation templates.Violation, fingerprint string, opts Options) []templates.Suppression {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	for _, w := range opts.Waivers {
+		if !w.matches(violation, fingerprint, now) {
+			continue
+		}
+		return []templates.Suppression{
+			{
+				Kind:          SUPPRESSION_KIND_EXTERNAL,
+				Status:        SUPPRESSION_STATUS_ACCEPTED,
+				Justification: w.Justification,
+				Properties: templates.SuppressionProperties{
+					Owner:   w.Owner,
+					Expires: w.Expires,
+				},
+			},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+const waiversYAML = `- policyId: P1
+  assetId: //storage.googleapis.com/projects/_/buckets/public-*
+  justification: Public website buckets
+  owner: web-team@example.com
+  expires: 2025-01-31
+- policyId: P2
+  justification: Accepted until the migration
+- assetId: //compute.googleapis.com/projects/p/zones/*/instances/*
+  justification: Expired
+  expires: 2024-01-01T00:00:00Z
+`
+
+func writeWaivers(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "waivers.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadWaivers(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantCount int
+		wantError bool
+	}{
+		{
+			name:      "ValidWaivers_Succeeds",
+			content:   waiversYAML,
+			wantCount: 3,
+		},
+		{
+			name:      "NoMatcher_Failure",
+			content:   "- justification: Everything\n",
+			wantError: true,
+		},
+		{
+			name:      "InvalidExpires_Failure",
+			content:   "- policyId: P1\n  justification: Accepted\n  expires: next year\n",
+			wantError: true,
+		},
+		{
+			name:      "InvalidPattern_Failure",
+			content:   "- assetId: \"[\"\n  justification: Accepted\n",
+			wantError: true,
+		},
+		{
+			name:      "NoJustification_Failure",
+			content:   "- policyId: P1\n",
+			wantError: true,
+		},
+		{
+			name:      "UnknownField_Failure",
+			content:   "- policyId: P1\n  asset_id: //storage.googleapis.com/projects/_/buckets/b\n  justification: Accepted\n",
+			wantError: true,
+		},
+		{
+			name:      "NotAList_Failure",
+			content:   "policyId: P1\n",
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			waivers, err := ReadWaivers(writeWaivers(t, test.content))
+			if (err != nil) != test.wantError {
+				t.Fatalf("Expected error: %v, got: %v", test.wantError, err)
+			}
+			if len(waivers) != test.wantCount {
+				t.Errorf("ReadWaivers() returned %d waivers, want %d", len(waivers), test.wantCount)
+			}
+		})
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	waivers, err := ReadWaivers(writeWaivers(t, waiversYAML))
+	if err != nil {
+		t.Fatalf("ReadWaivers() failed: %v", err)
+	}
+
+	response := templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{
+				{PolicyID: "P1", AssetID: "//storage.googleapis.com/projects/_/buckets/public-site", Severity: "HIGH"},
+				{PolicyID: "P1", AssetID: "//storage.googleapis.com/projects/_/buckets/private", Severity: "HIGH"},
+				{PolicyID: "P2", AssetID: "//storage.googleapis.com/projects/_/buckets/private", Severity: "LOW"},
+				{PolicyID: "P3", AssetID: "//compute.googleapis.com/projects/p/zones/z/instances/vm", Severity: "LOW"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want map[string][]templates.Suppression
+	}{
+		{
+			name: "BeforeExpiry",
+			now:  time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC),
+			want: map[string][]templates.Suppression{
+				"P1 //storage.googleapis.com/projects/_/buckets/public-site": {
+					{
+						Kind:          "external",
+						Status:        "accepted",
+						Justification: "Public website buckets",
+						Properties:    templates.SuppressionProperties{Owner: "web-team@example.com", Expires: "2025-01-31"},
+					},
+				},
+				"P2 //storage.googleapis.com/projects/_/buckets/private": {
+					{Kind: "external", Status: "accepted", Justification: "Accepted until the migration"},
+				},
+			},
+		},
+		{
+			name: "AfterExpiry",
+			now:  time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+			want: map[string][]templates.Suppression{
+				"P2 //storage.googleapis.com/projects/_/buckets/private": {
+					{Kind: "external", Status: "accepted", Justification: "Accepted until the migration"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := FromIACScanReport(response, Options{Waivers: waivers, Now: test.now})
+			if err != nil {
+				t.Fatalf("FromIACScanReport() failed: %v", err)
+			}
+
+			results := output.Runs[0].Results
+			if len(results) != len(response.IacValidationReport.Violations) {
+				t.Fatalf("FromIACScanReport() returned %d results, want waived violations to be kept", len(results))
+			}
+
+			got := make(map[string][]templates.Suppression)
+			for _, result := range results {
+				if result.Suppressions != nil {
+					got[result.RuleID+" "+result.Properties.AssetID] = result.Suppressions
+				}
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected suppressions (-want, +got): %v", diff)
+			}
+		})
+	}
+}
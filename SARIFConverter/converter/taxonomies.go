@@ -0,0 +1,173 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// RELATIONSHIP_KIND is the kind of the relationships between rules and the
+// controls of compliance standards.
+const RELATIONSHIP_KIND = "relevant"
+
+// KNOWN_STANDARDS are the compliance standards recognised at the start of the
+// compliance standard strings of a policy. More specific names come first so
+// that e.g. "CIS Controls" is not mistaken for the CIS benchmarks.
+var KNOWN_STANDARDS = []string{
+	"NIST 800-53",
+	"NIST CSF",
+	"NIST SP 800-53",
+	"PCI DSS",
+	"ISO 27001",
+	"ISO-27001",
+	"CIS Controls",
+	"CIS",
+	"SOC2",
+	"SOC 2",
+	"HIPAA",
+	"OWASP",
+}
+
+// complianceControl is a compliance standard string split into the standard,
+// including its version, and the control, e.g. "CIS 2.0 5.2" becomes "CIS
+// 2.0" and "5.2".
+type complianceControl struct {
+	standard string
+	control  string
+}
+
+// parseComplianceStandard splits s at its last space or colon. Known
+// standards are matched case-insensitively and use the casing of
+// KNOWN_STANDARDS. A string without control is a standard on its own.
+func parseComplianceStandard(s string) complianceControl {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ':'
+	})
+	if len(fields) == 0 {
+		return complianceControl{}
+	}
+
+	name := ""
+	for _, known := range KNOWN_STANDARDS {
+		knownFields := strings.Fields(known)
+		if len(fields) >= len(knownFields) && strings.EqualFold(strings.Join(fields[:len(knownFields)], " "), known) {
+			name = known
+			fields = fields[len(knownFields):]
+			break
+		}
+	}
+
+	if name == "" {
+		name, fields = fields[0], fields[1:]
+	}
+	if len(fields) == 0 {
+		return complianceControl{standard: name}
+	}
+
+	version := fields[:len(fields)-1]
+	return complianceControl{
+		standard: strings.Join(append([]string{name}, version...), " "),
+		control:  fields[len(fields)-1],
+	}
+}
+
+// constructTaxonomies returns a taxonomy per compliance standard of the
+// violated policies, with a taxon per control. Taxonomies are sorted by name
+// and taxa by ID.
+func constructTaxonomies(policyToViolationMap map[string]templates.Violation) []templates.ToolComponent {
+	controlsByStandard := make(map[string]map[string]bool)
+
+	for _, violation := range policyToViolationMap {
+		for _, s := range violation.ViolatedPolicy.ComplianceStandards {
+			c := parseComplianceStandard(s)
+			if c.standard == "" {
+				continue
+			}
+			if controlsByStandard[c.standard] == nil {
+				controlsByStandard[c.standard] = make(map[string]bool)
+			}
+			if c.control != "" {
+				controlsByStandard[c.standard][c.control] = true
+			}
+		}
+	}
+
+	taxonomies := []templates.ToolComponent{}
+	for standard, controls := range controlsByStandard {
+		taxa := []templates.Taxon{}
+		for control := range controls {
+			taxa = append(taxa, templates.Taxon{ID: control})
+		}
+		sort.Slice(taxa, func(i, j int) bool {
+			return taxa[i].ID < taxa[j].ID
+		})
+
+		taxonomies = append(taxonomies, templates.ToolComponent{Name: standard, Taxa: taxa})
+	}
+	sort.Slice(taxonomies, func(i, j int) bool {
+		return taxonomies[i].Name < taxonomies[j].Name
+	})
+
+	return taxonomies
+}
+
+// complianceRelationships links a rule to the controls of its compliance
+// standards.
+func complianceRelationships(standards []string) []templates.Relationship {
+	relationships := []templates.Relationship{}
+	for _, s := range standards {
+		c := parseComplianceStandard(s)
+		if c.control == "" {
+			continue
+		}
+		relationships = append(relationships, templates.Relationship{
+			Target: templates.DescriptorReference{
+				ID:            c.control,
+				ToolComponent: templates.ToolComponentReference{Name: c.standard},
+			},
+			Kinds: []string{RELATIONSHIP_KIND},
+		})
+	}
+
+	if len(relationships) == 0 {
+		return nil
+	}
+	return relationships
+}
+
+// complianceTags returns the security tag followed by the compliance
+// standards, both on their own and with their control, so that results can
+// be filtered by standard or by control.
+func complianceTags(standards []string) []string {
+	tags := []string{SECURITY_TAG}
+	seen := map[string]bool{SECURITY_TAG: true}
+
+	for _, s := range standards {
+		c := parseComplianceStandard(s)
+		for _, tag := range []string{c.standard, strings.TrimSpace(s)} {
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
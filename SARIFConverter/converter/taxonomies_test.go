@@ -0,0 +1,85 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestParseComplianceStandard(t *testing.T) {
+	tests := []struct {
+		input string
+		want  complianceControl
+	}{
+		{input: "CIS 2.0 5.2", want: complianceControl{standard: "CIS 2.0", control: "5.2"}},
+		{input: "cis controls 8.0 3.3", want: complianceControl{standard: "CIS Controls 8.0", control: "3.3"}},
+		{input: "NIST 800-53 R5 AC-3", want: complianceControl{standard: "NIST 800-53 R5", control: "AC-3"}},
+		{input: "PCI DSS 4.0: 1.2.1", want: complianceControl{standard: "PCI DSS 4.0", control: "1.2.1"}},
+		{input: "ISO 27001 A.12.4.1", want: complianceControl{standard: "ISO 27001", control: "A.12.4.1"}},
+		{input: "HIPAA", want: complianceControl{standard: "HIPAA"}},
+		{input: "Internal SEC-7", want: complianceControl{standard: "Internal", control: "SEC-7"}},
+		{input: "  ", want: complianceControl{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got := parseComplianceStandard(test.input)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(complianceControl{})); diff != "" {
+				t.Errorf("parseComplianceStandard(%q) unexpected result (-want, +got): %v", test.input, diff)
+			}
+		})
+	}
+}
+
+func TestConstructTaxonomies(t *testing.T) {
+	policyToViolationMap := map[string]templates.Violation{
+		"P1": {ViolatedPolicy: templates.PolicyDetails{ComplianceStandards: []string{"NIST 800-53 SC-7", "CIS 2.0 5.2"}}},
+		"P2": {ViolatedPolicy: templates.PolicyDetails{ComplianceStandards: []string{"CIS 2.0 3.1", "CIS 2.0 5.2", "HIPAA"}}},
+		"P3": {},
+	}
+
+	want := []templates.ToolComponent{
+		{Name: "CIS 2.0", Taxa: []templates.Taxon{{ID: "3.1"}, {ID: "5.2"}}},
+		{Name: "HIPAA", Taxa: []templates.Taxon{}},
+		{Name: "NIST 800-53", Taxa: []templates.Taxon{{ID: "SC-7"}}},
+	}
+
+	if diff := cmp.Diff(want, constructTaxonomies(policyToViolationMap)); diff != "" {
+		t.Errorf("constructTaxonomies() unexpected result (-want, +got): %v", diff)
+	}
+}
+
+func TestComplianceRelationshipsAndTags(t *testing.T) {
+	standards := []string{"CIS 2.0 5.2", "CIS 2.0 3.1", "HIPAA"}
+
+	wantRelationships := []templates.Relationship{
+		{Target: templates.DescriptorReference{ID: "5.2", ToolComponent: templates.ToolComponentReference{Name: "CIS 2.0"}}, Kinds: []string{"relevant"}},
+		{Target: templates.DescriptorReference{ID: "3.1", ToolComponent: templates.ToolComponentReference{Name: "CIS 2.0"}}, Kinds: []string{"relevant"}},
+	}
+	if diff := cmp.Diff(wantRelationships, complianceRelationships(standards)); diff != "" {
+		t.Errorf("complianceRelationships() unexpected result (-want, +got): %v", diff)
+	}
+
+	wantTags := []string{"security", "CIS 2.0", "CIS 2.0 5.2", "CIS 2.0 3.1", "HIPAA"}
+	if diff := cmp.Diff(wantTags, complianceTags(standards)); diff != "" {
+		t.Errorf("complianceTags() unexpected result (-want, +got): %v", diff)
+	}
+}
@@ -72,6 +72,32 @@ var IACValidationReportWithInvalidSeverity = templates.IACValidationReport{
 	},
 }
 
+var IACValidationReportWithLowercaseSeverity = templates.IACValidationReport{
+	Violations: []templates.Violation{
+		{
+			AssetID:  "Asset 1",
+			PolicyID: "P1",
+			Severity: "high",
+			ViolatedPolicy: templates.PolicyDetails{
+				Description:         "High-level violation message",
+				ConstraintType:      "Type 1",
+				ComplianceStandards: []string{"Standard 1"},
+			},
+			NextSteps: "Next steps 1",
+			ViolatedPosture: templates.PostureDetails{
+				PolicySet:         "Set 1",
+				Posture:           "Posture 1",
+				PostureRevisionID: "Rev 1",
+				PostureDeployment: "Dep 1",
+			},
+			ViolatedAsset: templates.AssetDetails{
+				AssetType: "Type 1",
+				Asset:     "Asset 1",
+			},
+		},
+	},
+}
+
 var IACValidSarifOutput = templates.SarifOutput{
 	Version: SARIF_VERSION,
 	Schema:  SARIF_SCHEMA,
@@ -84,8 +110,21 @@ var IACValidSarifOutput = templates.SarifOutput{
 					InformationURI: IAC_TOOL_DOCUMENTATION_LINK,
 					Rules: []templates.Rule{
 						{
-							ID:              "P1",
-							FullDescription: templates.FullDescription{Text: "High-level violation message"},
+							ID:               "P1",
+							Name:             "P1",
+							ShortDescription: templates.ShortDescription{Text: "High-level violation message"},
+							FullDescription:  templates.FullDescription{Text: "High-level violation message"},
+							Help: &templates.Help{
+								Text:     "Next steps: Next steps 1\nCompliance standards: Standard 1",
+								Markdown: "**Next steps:** Next steps 1\n\n**Compliance standards:**\n\n- Standard 1",
+							},
+							DefaultConfiguration: templates.DefaultConfiguration{Level: "error"},
+							Relationships: []templates.Relationship{
+								{
+									Target: templates.DescriptorReference{ID: "1", ToolComponent: templates.ToolComponentReference{Name: "Standard"}},
+									Kinds:  []string{"relevant"},
+								},
+							},
 							Properties: templates.RuleProperties{
 								Severity:            "HIGH",
 								PolicyType:          "Type 1",
@@ -95,14 +134,21 @@ var IACValidSarifOutput = templates.SarifOutput{
 								PostureRevisionID:   "Rev 1",
 								PostureDeploymentID: "Dep 1",
 								NextSteps:           "Next steps 1",
+								SecuritySeverity:    "8.0",
+								Tags:                []string{"security", "Standard", "Standard 1"},
 							},
 						},
 					},
 				},
 			},
+			Invocations: []templates.Invocation{{ExecutionSuccessful: true}},
+			Taxonomies: []templates.ToolComponent{
+				{Name: "Standard", Taxa: []templates.Taxon{{ID: "1"}}},
+			},
 			Results: []templates.Result{
 				{
 					RuleID:  "P1",
+					Level:   "error",
 					Message: templates.Message{Text: "Asset type: Type 1 has a violation, next steps: Next steps 1"},
 					Locations: []templates.Location{
 						{
@@ -111,13 +157,20 @@ var IACValidSarifOutput = templates.SarifOutput{
 							},
 						},
 					},
+					Fingerprints:        map[string]string{"sccIacViolation/v1": "09763277d41f0d6403b168732bc7b2469e4df8b991e0af9f2ca8a8cb75530672"},
+					PartialFingerprints: map[string]string{"sccIacViolation/v1": "09763277d41f0d6403b168732bc7b2469e4df8b991e0af9f2ca8a8cb75530672"},
 					Properties: templates.ResultProperties{
 						AssetID:   "Asset 1",
 						Asset:     "Asset 1",
 						AssetType: "Type 1",
+						Severity:  "HIGH",
 					},
 				},
 			},
+			Properties: templates.RunProperties{
+				SeverityCounts:  map[string]int{"CRITICAL": 0, "HIGH": 1, "MEDIUM": 0, "LOW": 0},
+				TotalViolations: 1,
+			},
 		},
 	},
 }
@@ -0,0 +1,173 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package htmlreport renders the IaC SCC scan report as a single, self-contained
+// HTML page that can be opened offline.
+package htmlreport
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+var reportHTML = template.Must(template.New("report").Parse(reportTemplate))
+
+type pageData struct {
+	Name           string
+	CreateTime     string
+	UpdateTime     string
+	Note           string
+	Total          int
+	Severities     []string
+	SeverityCounts []severityCount
+	Standards      []standardBreakdown
+	Violations     []violationRow
+}
+
+type severityCount struct {
+	Severity string
+	Count    int
+	Percent  int
+}
+
+type standardBreakdown struct {
+	Standard string
+	Counts   []int
+	Total    int
+}
+
+type violationRow struct {
+	Severity     string
+	SeverityRank int
+	PolicyID     string
+	Constraint   string
+	AssetID      string
+	AssetType    string
+	Posture      string
+	Standards    string
+	NextSteps    string
+	Description  string
+}
+
+// FromIACScanReport writes the HTML report for response to w. All styles and
+// scripts are inlined so the output does not depend on any network resource.
+func FromIACScanReport(w io.Writer, response templates.Responses) error {
+	if err := reportHTML.Execute(w, buildPageData(response)); err != nil {
+		return fmt.Errorf("reportHTML.Execute: %v", err)
+	}
+	return nil
+}
+
+func buildPageData(response templates.Responses) pageData {
+	violations := response.IacValidationReport.Violations
+
+	data := pageData{
+		Name:       response.Name,
+		CreateTime: response.CreateTime,
+		UpdateTime: response.UpdateTime,
+		Note:       response.IacValidationReport.Note,
+		Total:      len(violations),
+		Severities: severitiesOf(violations),
+		Violations: []violationRow{},
+	}
+
+	severityCounts := make(map[string]int)
+	standardCounts := make(map[string][]int)
+
+	for _, v := range violations {
+		s := strings.ToUpper(v.Severity)
+		severityCounts[s]++
+
+		rank := severityRank(data.Severities, s)
+		for _, standard := range v.ViolatedPolicy.ComplianceStandards {
+			if _, ok := standardCounts[standard]; !ok {
+				standardCounts[standard] = make([]int, len(data.Severities))
+			}
+			if rank < len(data.Severities) {
+				standardCounts[standard][rank]++
+			}
+		}
+
+		data.Violations = append(data.Violations, violationRow{
+			Severity:     s,
+			SeverityRank: rank,
+			PolicyID:     v.PolicyID,
+			Constraint:   v.ViolatedPolicy.Constraint,
+			AssetID:      v.AssetID,
+			AssetType:    v.ViolatedAsset.AssetType,
+			Posture:      v.ViolatedPosture.Posture,
+			Standards:    strings.Join(v.ViolatedPolicy.ComplianceStandards, ", "),
+			NextSteps:    v.NextSteps,
+			Description:  v.ViolatedPolicy.Description,
+		})
+	}
+
+	sort.SliceStable(data.Violations, func(i, j int) bool {
+		return data.Violations[i].SeverityRank < data.Violations[j].SeverityRank
+	})
+
+	for _, s := range data.Severities {
+		count := severityCounts[s]
+		percent := 0
+		if data.Total > 0 {
+			percent = count * 100 / data.Total
+		}
+		data.SeverityCounts = append(data.SeverityCounts, severityCount{Severity: s, Count: count, Percent: percent})
+	}
+
+	for standard, counts := range standardCounts {
+		total := 0
+		for _, c := range counts {
+			total += c
+		}
+		data.Standards = append(data.Standards, standardBreakdown{Standard: standard, Counts: counts, Total: total})
+	}
+	sort.Slice(data.Standards, func(i, j int) bool {
+		return data.Standards[i].Standard < data.Standards[j].Standard
+	})
+
+	return data
+}
+
+// severitiesOf returns the severities shown in the report. UNKNOWN is only
+// included when some violation has it.
+func severitiesOf(violations []templates.Violation) []string {
+	for _, v := range violations {
+		if strings.ToUpper(v.Severity) == severity.UNKNOWN {
+			return severity.All
+		}
+	}
+	return severity.Known
+}
+
+func severityRank(severities []string, s string) int {
+	for i, known := range severities {
+		if known == s {
+			return i
+		}
+	}
+	return len(severities)
+}
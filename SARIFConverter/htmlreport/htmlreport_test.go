@@ -0,0 +1,103 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package htmlreport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+var response = templates.Responses{
+	Name:       "organizations/123/locations/global/reports/abc",
+	CreateTime: "2024-05-01T10:00:00Z",
+	IacValidationReport: templates.IACValidationReport{
+		Note: "Scan <note>",
+		Violations: []templates.Violation{
+			{
+				PolicyID:       "P2",
+				AssetID:        "asset2",
+				Severity:       "low",
+				ViolatedPolicy: templates.PolicyDetails{ComplianceStandards: []string{"CIS 2.0 1.1"}},
+			},
+			{
+				PolicyID:       "P1",
+				AssetID:        "<script>alert(1)</script>",
+				Severity:       "CRITICAL",
+				ViolatedPolicy: templates.PolicyDetails{ComplianceStandards: []string{"CIS 2.0 1.1", "NIST 800-53 AC-3"}},
+			},
+		},
+	},
+}
+
+func TestFromIACScanReport(t *testing.T) {
+	var b bytes.Buffer
+	if err := FromIACScanReport(&b, response); err != nil {
+		t.Fatalf("FromIACScanReport() failed: %v", err)
+	}
+	got := b.String()
+
+	wantContain := []string{
+		"<dd>organizations/123/locations/global/reports/abc</dd>",
+		"<dd>2024-05-01T10:00:00Z</dd>",
+		`<p class="note">Scan &lt;note&gt;</p>`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		`<tr data-severity="CRITICAL">`,
+		"<td>NIST 800-53 AC-3</td>",
+	}
+	for _, want := range wantContain {
+		if !strings.Contains(got, want) {
+			t.Errorf("FromIACScanReport() output does not contain %q", want)
+		}
+	}
+
+	for _, external := range []string{"<script src", "<link", "@import"} {
+		if strings.Contains(got, external) {
+			t.Errorf("FromIACScanReport() output references external resource %q", external)
+		}
+	}
+}
+
+func TestBuildPageData(t *testing.T) {
+	data := buildPageData(response)
+
+	wantSeverityCounts := []severityCount{
+		{Severity: "CRITICAL", Count: 1, Percent: 50},
+		{Severity: "HIGH"},
+		{Severity: "MEDIUM"},
+		{Severity: "LOW", Count: 1, Percent: 50},
+	}
+	if diff := cmp.Diff(wantSeverityCounts, data.SeverityCounts); diff != "" {
+		t.Errorf("Unexpected severity counts (-want, +got): %v", diff)
+	}
+
+	wantStandards := []standardBreakdown{
+		{Standard: "CIS 2.0 1.1", Counts: []int{1, 0, 0, 1}, Total: 2},
+		{Standard: "NIST 800-53 AC-3", Counts: []int{1, 0, 0, 0}, Total: 1},
+	}
+	if diff := cmp.Diff(wantStandards, data.Standards); diff != "" {
+		t.Errorf("Unexpected standards breakdown (-want, +got): %v", diff)
+	}
+
+	if got := data.Violations[0].PolicyID; got != "P1" {
+		t.Errorf("Expected violations sorted by severity, first policy got: %v", got)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package junit writes the IaC SCC scan report as JUnit XML, so that CI
+// systems without SARIF support show the violations as failed tests.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// TESTSUITES_NAME names the root element, after the tool.
+const TESTSUITES_NAME = "analyze-code-security-scc"
+
+// TestSuites is the root element of a JUnit XML report.
+type TestSuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	Name       string      `xml:"name,attr"`
+	Tests      int         `xml:"tests,attr"`
+	Failures   int         `xml:"failures,attr"`
+	TestSuites []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite holds the violations of a single policy.
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	TestCases []TestCase `xml:"testcase"`
+}
+
+// TestCase is a single violation, named after the violating asset.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Failure   *Failure `xml:"failure"`
+}
+
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// FromIACScanReport returns a test suite per policy, sorted by policy ID, with
+// a failed test case per violation in the order of the report.
+func FromIACScanReport(response templates.Responses) TestSuites {
+	suiteIndexes := make(map[string]int)
+	suites := []TestSuite{}
+
+	for _, v := range response.IacValidationReport.Violations {
+		i, ok := suiteIndexes[v.PolicyID]
+		if !ok {
+			i = len(suites)
+			suiteIndexes[v.PolicyID] = i
+			suites = append(suites, TestSuite{Name: v.PolicyID, TestCases: []TestCase{}})
+		}
+
+		suites[i].TestCases = append(suites[i].TestCases, TestCase{
+			Name:      v.AssetID,
+			ClassName: v.PolicyID,
+			Failure: &Failure{
+				Message: fmt.Sprintf("%s violation of policy %s", v.Severity, v.PolicyID),
+				Type:    v.Severity,
+				Text:    v.NextSteps,
+			},
+		})
+		suites[i].Tests++
+		suites[i].Failures++
+	}
+
+	sort.SliceStable(suites, func(i, j int) bool {
+		return suites[i].Name < suites[j].Name
+	})
+
+	testSuites := TestSuites{Name: TESTSUITES_NAME, TestSuites: suites}
+	for _, suite := range suites {
+		testSuites.Tests += suite.Tests
+		testSuites.Failures += suite.Failures
+	}
+
+	return testSuites
+}
+
+// WriteJUnit writes the violations of response as JUnit XML.
+func WriteJUnit(w io.Writer, response templates.Responses) error {
+	reportXML, err := xml.MarshalIndent(FromIACScanReport(response), "", "  ")
+	if err != nil {
+		return fmt.Errorf("xml.MarshalIndent: %v", err)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("io.WriteString: %v", err)
+	}
+	if _, err := w.Write(append(reportXML, '\n')); err != nil {
+		return fmt.Errorf("w.Write: %v", err)
+	}
+
+	return nil
+}
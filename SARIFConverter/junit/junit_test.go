@@ -0,0 +1,78 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package junit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+var response = templates.Responses{
+	IacValidationReport: templates.IACValidationReport{
+		Violations: []templates.Violation{
+			{AssetID: "asset2", PolicyID: "P2", Severity: "LOW"},
+			{AssetID: "asset1", PolicyID: "P1", Severity: "HIGH", NextSteps: "Enable <uniform> access."},
+			{AssetID: "asset3", PolicyID: "P2", Severity: "LOW"},
+		},
+	},
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJUnit(&b, response); err != nil {
+		t.Fatalf("WriteJUnit() failed: %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="analyze-code-security-scc" tests="3" failures="3">
+  <testsuite name="P1" tests="1" failures="1">
+    <testcase name="asset1" classname="P1">
+      <failure message="HIGH violation of policy P1" type="HIGH">Enable &lt;uniform&gt; access.</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="P2" tests="2" failures="2">
+    <testcase name="asset2" classname="P2">
+      <failure message="LOW violation of policy P2" type="LOW"></failure>
+    </testcase>
+    <testcase name="asset3" classname="P2">
+      <failure message="LOW violation of policy P2" type="LOW"></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteJUnit() unexpected output (-want, +got): %v", diff)
+	}
+}
+
+func TestWriteJUnit_NoViolations(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJUnit(&b, templates.Responses{}); err != nil {
+		t.Fatalf("WriteJUnit() failed: %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="analyze-code-security-scc" tests="0" failures="0"></testsuites>
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteJUnit() unexpected output (-want, +got): %v", diff)
+	}
+}
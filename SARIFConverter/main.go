@@ -14,74 +14,545 @@
  limitations under the License.
 */
 
-// Package main converts IaC validation report to SARIF JSON format.
+// Package main converts IaC validation report to SARIF JSON format, to a
+// Markdown pull request comment, to a self-contained HTML report, to CSV and
+// newline-delimited JSON tables or to JUnit XML.
 package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/converter"
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/htmlreport"
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/junit"
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/markdown"
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/sarifschema"
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/tabular"
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/terraform"
+	"github.com/google/gcp-scc-iac-validation-utils/loader"
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
 	"github.com/google/gcp-scc-iac-validation-utils/templates"
 )
 
 var (
-	inputFilePath  = flag.String("inputFilePath", "", "path of the input file")
-	outputFilePath = flag.String("outputFilePath", "output.json", "path of the output file")
+	inputFilePaths  = newStringList("inputFilePath", "path of the input file in JSON or YAML, optionally gzip compressed, or - for stdin; can be repeated to convert several reports")
+	merge           = flag.Bool("merge", false, "merge the SARIF outputs of this tool given in inputFilePath instead of converting IaC validation reports")
+	validate        = flag.Bool("validate", false, "check the SARIF documents given in inputFilePath against the SARIF 2.1.0 schema instead of converting IaC validation reports")
+	outputFilePath  = flag.String("outputFilePath", "output.json", "path of the output file, or - for stdout")
+	outputFormat    = flag.String("outputFormat", "sarif", "format of the output file: sarif, markdown, html, csv, ndjson, junit or bigquery_schema")
+	formats         = newStringList("format", "output as format:path, e.g. sarif:out.sarif, junit:out.xml or md:- for stdout; can be repeated to write several outputs from one parse of the reports, and replaces outputFormat and outputFilePath")
+	baselinePath    = flag.String("baselineFilePath", "", "path of a previous IaC validation report to diff against, only used by the markdown format")
+	strict          = flag.Bool("strict", false, "reject reports with unknown fields, wrong types or missing violation fields")
+	unknownSeverity = flag.String("unknown_severity", "fail", "handling of severities other than critical, high, medium and low: fail, unknown or map:<SEVERITY>")
+	severityLevels  = flag.String("severity_levels", "", "comma separated SEVERITY:level overrides of the SARIF result levels, e.g. high:warning,low:none")
+	sourceDir       = flag.String("source_dir", "", "directory of the Terraform configuration, used to add the file and lines of the violating resources to the SARIF results")
+	waiversPath     = flag.String("suppressions_file", "", "path of a JSON or YAML list of waivers, matching results are marked as suppressed instead of active")
+	baselineSarif   = flag.String("baseline_sarif", "", "path of a previous SARIF output of this tool, used to set the baselineState of the results")
+	maxPerRule      = flag.Int("max_results_per_rule", 0, "keep at most this many SARIF results per rule, active and most severe first; 0 for no limit")
+	maxPerRun       = flag.Int("max_results_per_run", 0, fmt.Sprintf("keep at most this many SARIF results per run, active and most severe first; 0 for no limit, GitHub accepts %d", converter.GITHUB_MAX_RESULTS_PER_RUN))
+	maxPerFile      = flag.Int("max_results_per_file", 0, "split the SARIF output into numbered files with at most this many results each; 0 for no limit")
+	maxFileSize     = flag.Int("max_file_size", 0, fmt.Sprintf("split the SARIF output into numbered files of at most this many bytes once gzip compressed; 0 for no limit, GitHub accepts %d", converter.GITHUB_MAX_FILE_SIZE))
+	partsPerRun     = flag.Int("parts_per_run", converter.DEFAULT_PARTS_PER_RUN, "number of parts, each of its own category, every run is divided into when the SARIF output has file limits; keep it the same from one scan to the next")
+	ruleKey         = flag.String("rule_key", converter.RULE_KEY_POLICY, "what SARIF rules are made per: policy, or policy_revision to keep the posture revisions of a policy apart")
+	failOnConflict  = flag.Bool("fail_on_conflict", false, "fail when violations of the same rule disagree on its severity, description, constraint or posture instead of adding a warning to the SARIF output")
+	postureExts     = flag.Bool("posture_extensions", false, "emit every posture revision as a SARIF tool extension owning its rules instead of listing all rules in the driver")
+	terraformPlan   = flag.String("terraform_plan", "", "path of the plan in JSON, as printed by terraform show -json, used to resolve assets by name; requires source_dir")
 )
 
 func main() {
 	flag.Parse()
 
-	iacReport, err := readAndParseIACScanReport(inputFilePath)
+	outputs, err := parseOutputs(*formats, *outputFormat, *outputFilePath)
 	if err != nil {
-		fmt.Printf("readAndParseIACScanReport: %v", err)
+		fmt.Printf("parseOutputs(): %v", err)
 		os.Exit(1)
 	}
 
-	sarifReport, err := converter.FromIACScanReport(iacReport.Response.IacValidationReport)
+	// The BigQuery schema does not depend on the report, so that the table
+	// can be created before any scan ran.
+	if onlyFormat(outputs, FORMAT_BIGQUERY_SCHEMA) {
+		for _, out := range outputs {
+			if err := writeOutputFile(&out.path, tabular.WriteBigQuerySchema); err != nil {
+				fmt.Printf("writeOutputFile(): %v", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	severityPolicy, err := severity.ParsePolicy(*unknownSeverity)
+	if err != nil {
+		fmt.Printf("severity.ParsePolicy: %v", err)
+		os.Exit(1)
+	}
+
+	levels, err := converter.ParseLevels(*severityLevels)
+	if err != nil {
+		fmt.Printf("converter.ParseLevels: %v", err)
+		os.Exit(1)
+	}
+
+	key, err := converter.ParseRuleKey(*ruleKey)
 	if err != nil {
-		fmt.Printf("converter.FromIACScanReport: %v", err)
+		fmt.Printf("converter.ParseRuleKey: %v", err)
 		os.Exit(1)
 	}
 
-	if err := writeSarifReport(sarifReport, outputFilePath); err != nil {
-		fmt.Printf("writeSarifReport(): %v", err)
+	if len(*inputFilePaths) == 0 {
+		fmt.Printf("inputFilePath is required")
 		os.Exit(1)
 	}
+
+	if *validate {
+		valid, err := validateSarifReports(*inputFilePaths)
+		if err != nil {
+			fmt.Printf("validateSarifReports(): %v", err)
+			os.Exit(1)
+		}
+		if !valid {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *merge {
+		if !onlyFormat(outputs, FORMAT_SARIF) {
+			fmt.Printf("merge only writes the sarif format")
+			os.Exit(1)
+		}
+		if err := mergeSarifReports(*inputFilePaths, outputs); err != nil {
+			fmt.Printf("mergeSarifReports(): %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	iacReports := []templates.IACReportTemplate{}
+	for _, inputFilePath := range *inputFilePaths {
+		iacReport, err := readAndParseIACScanReport(inputFilePath, severityPolicy)
+		if err != nil {
+			fmt.Printf("readAndParseIACScanReport(%s): %v", inputFilePath, err)
+			os.Exit(1)
+		}
+		iacReports = append(iacReports, iacReport)
+	}
+	// Formats other than SARIF show the violations of all reports together.
+	iacReport := loader.Merge(iacReports)
+
+	// The SARIF report is converted once, however many SARIF outputs there are.
+	var sarifReport *templates.SarifOutput
+
+	for _, out := range outputs {
+		switch out.format {
+		case FORMAT_SARIF:
+			if sarifReport == nil {
+				converted, err := convertToSarif(iacReports, converter.Options{SeverityPolicy: severityPolicy, Levels: levels, RuleKey: key, FailOnConflict: *failOnConflict})
+				if err != nil {
+					fmt.Printf("convertToSarif(): %v", err)
+					os.Exit(1)
+				}
+				sarifReport = &converted
+			}
+
+			if err := writeSarifReports(*sarifReport, &out.path); err != nil {
+				fmt.Printf("writeSarifReports(): %v", err)
+				os.Exit(1)
+			}
+		case FORMAT_MARKDOWN:
+			var baseline *templates.IACValidationReport
+			if *baselinePath != "" {
+				baselineReport, err := readAndParseIACScanReport(*baselinePath, severityPolicy)
+				if err != nil {
+					fmt.Printf("readAndParseIACScanReport(baseline): %v", err)
+					os.Exit(1)
+				}
+				baseline = &baselineReport.Response.IacValidationReport
+			}
+
+			comment := markdown.FromIACScanReport(iacReport.Response.IacValidationReport, baseline, markdown.Options{})
+			if err := writeOutputFile(&out.path, func(w io.Writer) error {
+				_, err := io.WriteString(w, comment)
+				return err
+			}); err != nil {
+				fmt.Printf("writeOutputFile(): %v", err)
+				os.Exit(1)
+			}
+		default:
+			write := reportWriters[out.format]
+			if err := writeOutputFile(&out.path, func(w io.Writer) error {
+				return write(w, iacReport.Response)
+			}); err != nil {
+				fmt.Printf("writeOutputFile(): %v", err)
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+// Output formats, see parseOutputs.
+const (
+	FORMAT_SARIF           = "sarif"
+	FORMAT_MARKDOWN        = "markdown"
+	FORMAT_BIGQUERY_SCHEMA = "bigquery_schema"
+)
+
+// STDOUT is the output file path that writes to standard output.
+const STDOUT = "-"
+
+// reportWriters are the formats other than SARIF and Markdown, which only
+// depend on the report.
+var reportWriters = map[string]func(io.Writer, templates.Responses) error{
+	"html":   htmlreport.FromIACScanReport,
+	"csv":    tabular.WriteCSV,
+	"ndjson": tabular.WriteNDJSON,
+	"junit":  junit.WriteJUnit,
+	FORMAT_BIGQUERY_SCHEMA: func(w io.Writer, _ templates.Responses) error {
+		return tabular.WriteBigQuerySchema(w)
+	},
+}
+
+// formatAliases are the short names accepted by --format.
+var formatAliases = map[string]string{
+	"md": FORMAT_MARKDOWN,
 }
 
-func readAndParseIACScanReport(filePath *string) (templates.IACReportTemplate, error) {
-	data, err := os.ReadFile(*filePath)
+type output struct {
+	format string
+	path   string
+}
+
+// parseOutputs parses the repeated kind:path values of --format, e.g.
+// sarif:out.sarif or md:- for standard output. Without any, the single output
+// is given by outputFormat and outputFilePath.
+func parseOutputs(formats []string, outputFormat, outputFilePath string) ([]output, error) {
+	if len(formats) == 0 {
+		formats = []string{outputFormat + ":" + outputFilePath}
+	}
+
+	outputs := []output{}
+	for _, value := range formats {
+		format, path, ok := strings.Cut(value, ":")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid format, want kind:path: %s", value)
+		}
+
+		format = strings.ToLower(strings.TrimSpace(format))
+		if alias, ok := formatAliases[format]; ok {
+			format = alias
+		}
+		if _, ok := reportWriters[format]; !ok && format != FORMAT_SARIF && format != FORMAT_MARKDOWN {
+			return nil, fmt.Errorf("unsupported output format: %s", format)
+		}
+
+		outputs = append(outputs, output{format: format, path: path})
+	}
+
+	return outputs, nil
+}
+
+// onlyFormat reports whether all outputs are of format.
+func onlyFormat(outputs []output, format string) bool {
+	for _, out := range outputs {
+		if out.format != format {
+			return false
+		}
+	}
+	return true
+}
+
+// convertToSarif converts every report to a run of its own, told apart by a
+// category derived from its file name, and merges them into one SARIF report.
+func convertToSarif(iacReports []templates.IACReportTemplate, opts converter.Options) (templates.SarifOutput, error) {
+	sources, err := indexSources(sourceDir, terraformPlan)
 	if err != nil {
-		return templates.IACReportTemplate{}, fmt.Errorf("os.ReadFile(%s): %v", *filePath, err)
+		return templates.SarifOutput{}, fmt.Errorf("indexSources(): %v", err)
+	}
+	opts.Sources = sources
+
+	if *waiversPath != "" {
+		opts.Waivers, err = converter.ReadWaivers(*waiversPath)
+		if err != nil {
+			return templates.SarifOutput{}, fmt.Errorf("converter.ReadWaivers: %v", err)
+		}
+	}
+
+	if *baselineSarif != "" {
+		baselineReport, err := converter.ReadSarifReport(*baselineSarif)
+		if err != nil {
+			return templates.SarifOutput{}, fmt.Errorf("converter.ReadSarifReport: %v", err)
+		}
+		opts.Baseline = &baselineReport
+	}
+
+	categories := runCategories(*inputFilePaths)
+	sarifReports := []templates.SarifOutput{}
+	for i, iacReport := range iacReports {
+		opts.Category = categories[i]
+		sarifReport, err := converter.FromIACScanReport(iacReport.Response, opts)
+		if err != nil {
+			return templates.SarifOutput{}, fmt.Errorf("converter.FromIACScanReport(%s): %v", (*inputFilePaths)[i], err)
+		}
+		sarifReports = append(sarifReports, sarifReport)
 	}
 
-	var iacReport templates.IACReportTemplate
-	if err = json.Unmarshal(data, &iacReport); err != nil {
-		return templates.IACReportTemplate{}, fmt.Errorf("json.Unmarshal(): %v", err)
+	sarifReport, err := converter.Merge(sarifReports)
+	if err != nil {
+		return templates.SarifOutput{}, fmt.Errorf("converter.Merge: %v", err)
 	}
 
+	return sarifReport, nil
+}
+
+// readAndParseIACScanReport reads the report and normalises the severities of
+// its violations, so that every output format sees the same values.
+func readAndParseIACScanReport(filePath string, severityPolicy severity.Policy) (templates.IACReportTemplate, error) {
+	iacReport, err := loader.ReadIACScanReport(filePath, loader.Options{Strict: *strict})
+	if err != nil {
+		return templates.IACReportTemplate{}, fmt.Errorf("loader.ReadIACScanReport: %v", err)
+	}
+
+	violations, err := severity.NormalizeViolations(iacReport.Response.IacValidationReport.Violations, severityPolicy)
+	if err != nil {
+		return templates.IACReportTemplate{}, fmt.Errorf("severity.NormalizeViolations(): %v", err)
+	}
+	iacReport.Response.IacValidationReport.Violations = violations
+
 	return iacReport, nil
 }
 
+// runCategories returns the SARIF run category of each input file. A single
+// report needs none. Otherwise the file name without extensions is used, or
+// the path below the common directory of the inputs when file names are
+// shared, e.g. net/report for net/report.json and app/report.json.
+func runCategories(filePaths []string) []string {
+	categories := make([]string, len(filePaths))
+	if len(filePaths) == 1 {
+		return categories
+	}
+
+	seen := make(map[string]bool)
+	shared := false
+	for i, filePath := range filePaths {
+		categories[i] = trimExtensions(filepath.Base(filePath))
+		shared = shared || seen[categories[i]]
+		seen[categories[i]] = true
+	}
+	if !shared {
+		return categories
+	}
+
+	parts := make([][]string, len(filePaths))
+	common := -1
+	for i, filePath := range filePaths {
+		parts[i] = strings.Split(filepath.ToSlash(filepath.Clean(filePath)), "/")
+		if common < 0 || len(parts[i])-1 < common {
+			common = len(parts[i]) - 1
+		}
+		for j := 0; j < common; j++ {
+			if parts[i][j] != parts[0][j] {
+				common = j
+			}
+		}
+	}
+
+	for i := range filePaths {
+		categories[i] = trimExtensions(strings.Join(parts[i][common:], "/"))
+	}
+
+	return categories
+}
+
+func trimExtensions(filePath string) string {
+	if filePath == loader.STDIN {
+		return "stdin"
+	}
+	for _, ext := range []string{".gz", ".json", ".yaml", ".yml", ".sarif"} {
+		filePath = strings.TrimSuffix(filePath, ext)
+	}
+	return filePath
+}
+
+// mergeSarifReports merges SARIF outputs of this tool, e.g. of several
+// pipelines, into a single file per output. Runs without a category get the
+// category of their file, as when converting several reports at once.
+func mergeSarifReports(filePaths []string, outputs []output) error {
+	categories := runCategories(filePaths)
+	sarifReports := []templates.SarifOutput{}
+	for i, filePath := range filePaths {
+		sarifReport, err := converter.ReadSarifReport(filePath)
+		if err != nil {
+			return fmt.Errorf("converter.ReadSarifReport(%s): %v", filePath, err)
+		}
+		sarifReports = append(sarifReports, converter.Categorize(sarifReport, categories[i]))
+	}
+
+	merged, err := converter.Merge(sarifReports)
+	if err != nil {
+		return fmt.Errorf("converter.Merge: %v", err)
+	}
+
+	for _, out := range outputs {
+		if err := writeSarifReports(merged, &out.path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSarifReports applies the result limits and writes the report, split
+// into numbered files, e.g. output-1.json and output-2.json, when it exceeds
+// the file limits, with the rules moved into posture extensions if requested.
+func writeSarifReports(sarifReport templates.SarifOutput, outputFilePath *string) error {
+	sarifReport = converter.LimitResults(sarifReport, *maxPerRule, *maxPerRun)
+
+	sarifReports, err := converter.Split(sarifReport, *maxPerFile, *maxFileSize, *partsPerRun)
+	if err != nil {
+		return fmt.Errorf("converter.Split: %v", err)
+	}
+
+	if *postureExts {
+		for i := range sarifReports {
+			sarifReports[i] = converter.PostureExtensions(sarifReports[i])
+		}
+	}
+
+	if len(sarifReports) == 1 {
+		return writeSarifReport(sarifReports[0], outputFilePath)
+	}
+	if *outputFilePath == STDOUT {
+		return fmt.Errorf("the SARIF output needs %d files and can't be written to stdout", len(sarifReports))
+	}
+
+	ext := filepath.Ext(*outputFilePath)
+	base := strings.TrimSuffix(*outputFilePath, ext)
+	for i, part := range sarifReports {
+		partPath := fmt.Sprintf("%s-%d%s", base, i+1, ext)
+		if err := writeSarifReport(part, &partPath); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "wrote %s\n", partPath)
+	}
+
+	return nil
+}
+
+// validateSarifReports prints the schema violations of every SARIF document
+// and reports whether all of them conform.
+func validateSarifReports(filePaths []string) (bool, error) {
+	valid := true
+	for _, filePath := range filePaths {
+		document, err := loader.ReadInput(filePath)
+		if err != nil {
+			return false, fmt.Errorf("loader.ReadInput(%s): %v", filePath, err)
+		}
+
+		err = sarifschema.Validate(document)
+		var validationErr *sarifschema.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			valid = false
+			for _, problem := range validationErr.Problems {
+				fmt.Printf("%s: %s\n", filePath, problem)
+			}
+		case err != nil:
+			return false, fmt.Errorf("sarifschema.Validate(%s): %v", filePath, err)
+		}
+	}
+
+	return valid, nil
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func newStringList(name, usage string) *stringList {
+	list := &stringList{}
+	flag.Var(list, name, usage)
+	return list
+}
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// indexSources returns nil when no source directory is given, in which case
+// the results only carry the logical location of the asset.
+func indexSources(sourceDir, planPath *string) (*terraform.Index, error) {
+	if *sourceDir == "" {
+		if *planPath != "" {
+			return nil, fmt.Errorf("terraform_plan requires source_dir")
+		}
+		return nil, nil
+	}
+
+	sources, err := terraform.IndexSourceDir(*sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("terraform.IndexSourceDir: %v", err)
+	}
+
+	if *planPath != "" {
+		if err := sources.AddPlan(*planPath); err != nil {
+			return nil, fmt.Errorf("sources.AddPlan: %v", err)
+		}
+	}
+
+	return sources, nil
+}
+
 func writeSarifReport(sarifReport templates.SarifOutput, outputFilePath *string) error {
 	sarifJSON, err := json.MarshalIndent(sarifReport, "", "  ")
 	if err != nil {
 		return fmt.Errorf("json.MarshalIndent: %v", err)
 	}
 
-	outputJSON, err := os.Create(*outputFilePath)
-	if err != nil {
-		return fmt.Errorf("os.Create: %v", err)
+	return writeOutputFile(outputFilePath, func(w io.Writer) error {
+		if _, err := w.Write(sarifJSON); err != nil {
+			return fmt.Errorf("w.Write: %v", err)
+		}
+		return nil
+	})
+}
+
+// writeOutputFile writes to stdout for STDOUT. Otherwise it writes to a
+// temporary file next to outputFilePath that is only renamed to it once
+// complete, so that readers never see a partial file.
+func writeOutputFile(outputFilePath *string, write func(io.Writer) error) error {
+	if *outputFilePath == STDOUT {
+		return write(os.Stdout)
 	}
-	defer outputJSON.Close()
 
-	_, err = outputJSON.Write(sarifJSON)
+	tempFile, err := os.CreateTemp(filepath.Dir(*outputFilePath), "."+filepath.Base(*outputFilePath)+".*.tmp")
 	if err != nil {
-		return fmt.Errorf("outputJSON.Write: %v", err)
+		return fmt.Errorf("os.CreateTemp: %v", err)
+	}
+	// Only removes anything when the file wasn't renamed.
+	defer os.Remove(tempFile.Name())
+
+	if err := write(tempFile); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("tempFile.Close: %v", err)
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		return fmt.Errorf("os.Chmod: %v", err)
+	}
+	if err := os.Rename(tempFile.Name(), *outputFilePath); err != nil {
+		return fmt.Errorf("os.Rename: %v", err)
 	}
 
 	return nil
@@ -0,0 +1,316 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package markdown renders the IaC SCC scan report as a compact Markdown
+// comment suitable for posting on a pull request.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/severity"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+const (
+	// DEFAULT_MAX_BYTES keeps the comment below the 65536 character limit
+	// enforced by GitHub on issue and pull request comments.
+	DEFAULT_MAX_BYTES = 60000
+	// DEFAULT_MAX_ASSETS_PER_POLICY is the number of assets listed under a
+	// policy before the remaining ones are summarised in a "N more" line.
+	DEFAULT_MAX_ASSETS_PER_POLICY = 20
+	// DEFAULT_MAX_DIFF_ENTRIES is the number of new or fixed violations
+	// listed in the baseline diff section.
+	DEFAULT_MAX_DIFF_ENTRIES = 20
+
+	BADGE_URL = "https://img.shields.io/badge/"
+	TITLE     = "SCC IaC validation report"
+
+	POLICIES_HEADING = "### Violations by policy\n\n"
+)
+
+// Options controls the size of the rendered comment. Zero values fall back to
+// the defaults above.
+type Options struct {
+	MaxBytes           int
+	MaxAssetsPerPolicy int
+	MaxDiffEntries     int
+}
+
+type policySection struct {
+	policyID   string
+	violations []templates.Violation
+}
+
+// FromIACScanReport renders report as Markdown. When baseline is not nil the
+// comment also lists the violations introduced and fixed relative to it.
+func FromIACScanReport(report templates.IACValidationReport, baseline *templates.IACValidationReport, opts Options) string {
+	opts = withDefaults(opts)
+
+	sections := groupByPolicy(report.Violations)
+
+	// The baseline diff leaves room for the policy heading and, should no
+	// policy section fit, the footer listing them all as not shown.
+	reserved := 0
+	if len(sections) > 0 {
+		reserved = len(POLICIES_HEADING) + len(policiesFooter(len(sections)))
+	}
+
+	var b strings.Builder
+	writeHeader(&b, report)
+	if baseline != nil {
+		writeBaselineDiff(&b, report, *baseline, opts, opts.MaxBytes-reserved)
+	}
+
+	if len(sections) == 0 {
+		return b.String()
+	}
+
+	b.WriteString(POLICIES_HEADING)
+
+	for i, section := range sections {
+		rendered := renderPolicySection(section, opts)
+		footer := policiesFooter(len(sections) - i)
+		if b.Len()+len(rendered)+len(footer) > opts.MaxBytes {
+			b.WriteString(footer)
+			break
+		}
+		b.WriteString(rendered)
+	}
+
+	return b.String()
+}
+
+func policiesFooter(notShown int) string {
+	return fmt.Sprintf("_… and %d more policies not shown._\n", notShown)
+}
+
+func withDefaults(opts Options) Options {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DEFAULT_MAX_BYTES
+	}
+	if opts.MaxAssetsPerPolicy <= 0 {
+		opts.MaxAssetsPerPolicy = DEFAULT_MAX_ASSETS_PER_POLICY
+	}
+	if opts.MaxDiffEntries <= 0 {
+		opts.MaxDiffEntries = DEFAULT_MAX_DIFF_ENTRIES
+	}
+	return opts
+}
+
+func writeHeader(b *strings.Builder, report templates.IACValidationReport) {
+	severityCounts := countBySeverity(report.Violations)
+
+	fmt.Fprintf(b, "## %s\n\n", TITLE)
+	b.WriteString(verdictBadge(len(report.Violations)))
+	b.WriteString("\n\n")
+
+	b.WriteString("| Severity | Violations |\n")
+	b.WriteString("| --- | ---: |\n")
+	for _, s := range severity.All {
+		if s == severity.UNKNOWN && severityCounts[s] == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "| %s | %d |\n", s, severityCounts[s])
+	}
+	fmt.Fprintf(b, "| **Total** | **%d** |\n\n", len(report.Violations))
+
+	if report.Note != "" {
+		fmt.Fprintf(b, "> %s\n\n", escape(report.Note))
+	}
+}
+
+func verdictBadge(violationCount int) string {
+	if violationCount == 0 {
+		return fmt.Sprintf("![IaC validation: passed](%sIaC%%20validation-passed-brightgreen)", BADGE_URL)
+	}
+	return fmt.Sprintf("![IaC validation: %d violations](%sIaC%%20validation-%d%%20violations-red)", violationCount, BADGE_URL, violationCount)
+}
+
+// writeBaselineDiff lists the introduced and fixed violations, keeping b
+// within maxBytes by summarising the entries that don't fit in a "N more" line.
+func writeBaselineDiff(b *strings.Builder, report, baseline templates.IACValidationReport, opts Options, maxBytes int) {
+	introduced := difference(report.Violations, baseline.Violations)
+	fixed := difference(baseline.Violations, report.Violations)
+	unchanged := len(report.Violations) - len(introduced)
+
+	b.WriteString("### Changes since baseline\n\n")
+	fmt.Fprintf(b, "**%d** new, **%d** fixed, **%d** unchanged.\n\n", len(introduced), len(fixed), unchanged)
+
+	// The list of new violations leaves room for the fixed ones to say how
+	// many there are.
+	writeDiffList(b, "New violations", introduced, opts, maxBytes-diffListSize("Fixed violations", fixed))
+	writeDiffList(b, "Fixed violations", fixed, opts, maxBytes)
+}
+
+const DIFF_LIST_END = "\n</details>\n\n"
+
+func writeDiffList(b *strings.Builder, title string, violations []templates.Violation, opts Options, maxBytes int) {
+	if len(violations) == 0 || b.Len()+diffListSize(title, violations) > maxBytes {
+		return
+	}
+
+	fmt.Fprintf(b, "<details>\n<summary>%s (%d)</summary>\n\n", title, len(violations))
+	for i, v := range violations {
+		more := fmt.Sprintf("- _… and %d more_\n", len(violations)-i)
+		entry := fmt.Sprintf("- **%s** `%s` on `%s`\n", escape(v.Severity), escapeCode(v.PolicyID), escapeCode(v.AssetID))
+		// Unless this is the last entry, the "N more" line must still fit
+		// after it.
+		after := len(DIFF_LIST_END)
+		if i < len(violations)-1 {
+			after += len(more)
+		}
+		if i == opts.MaxDiffEntries || b.Len()+len(entry)+after > maxBytes {
+			b.WriteString(more)
+			break
+		}
+		b.WriteString(entry)
+	}
+	b.WriteString(DIFF_LIST_END)
+}
+
+// diffListSize returns the size of the list of violations when none of them
+// fit and the list only says how many there are.
+func diffListSize(title string, violations []templates.Violation) int {
+	if len(violations) == 0 {
+		return 0
+	}
+	return len(fmt.Sprintf("<details>\n<summary>%s (%d)</summary>\n\n- _… and %d more_\n", title, len(violations), len(violations))) + len(DIFF_LIST_END)
+}
+
+func renderPolicySection(section policySection, opts Options) string {
+	var b strings.Builder
+	first := section.violations[0]
+
+	fmt.Fprintf(&b, "<details>\n<summary><b>%s</b> <code>%s</code> (%d %s)</summary>\n\n",
+		html.EscapeString(first.Severity), html.EscapeString(section.policyID), len(section.violations), plural(len(section.violations), "asset", "assets"))
+
+	if first.ViolatedPolicy.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", escape(first.ViolatedPolicy.Description))
+	}
+	if first.NextSteps != "" {
+		fmt.Fprintf(&b, "**Next steps:** %s\n\n", escape(first.NextSteps))
+	}
+
+	for i, v := range section.violations {
+		if i == opts.MaxAssetsPerPolicy {
+			fmt.Fprintf(&b, "- _… and %d more_\n", len(section.violations)-i)
+			break
+		}
+		if v.ViolatedAsset.AssetType != "" {
+			fmt.Fprintf(&b, "- `%s` (%s)\n", escapeCode(v.AssetID), escape(v.ViolatedAsset.AssetType))
+			continue
+		}
+		fmt.Fprintf(&b, "- `%s`\n", escapeCode(v.AssetID))
+	}
+	b.WriteString("\n</details>\n\n")
+
+	return b.String()
+}
+
+// groupByPolicy groups the violations per policy, ordering the policies by
+// severity and then by policy ID so that the comment is stable across runs.
+func groupByPolicy(violations []templates.Violation) []policySection {
+	indexByPolicy := make(map[string]int)
+	sections := []policySection{}
+
+	for _, v := range violations {
+		i, ok := indexByPolicy[v.PolicyID]
+		if !ok {
+			i = len(sections)
+			indexByPolicy[v.PolicyID] = i
+			sections = append(sections, policySection{policyID: v.PolicyID})
+		}
+		sections[i].violations = append(sections[i].violations, v)
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		ri := severityRank(sections[i].violations[0].Severity)
+		rj := severityRank(sections[j].violations[0].Severity)
+		if ri != rj {
+			return ri < rj
+		}
+		return sections[i].policyID < sections[j].policyID
+	})
+
+	return sections
+}
+
+func countBySeverity(violations []templates.Violation) map[string]int {
+	severityCounts := make(map[string]int)
+	for _, v := range violations {
+		severityCounts[strings.ToUpper(v.Severity)]++
+	}
+	return severityCounts
+}
+
+func severityRank(s string) int {
+	return severity.Rank(strings.ToUpper(s))
+}
+
+// difference returns the violations of a that have no counterpart in b. Two
+// violations are considered the same when they share policy and asset.
+func difference(a, b []templates.Violation) []templates.Violation {
+	seen := make(map[string]bool)
+	for _, v := range b {
+		seen[violationKey(v)] = true
+	}
+
+	diff := []templates.Violation{}
+	for _, v := range a {
+		if !seen[violationKey(v)] {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+func violationKey(v templates.Violation) string {
+	return v.PolicyID + "\x00" + v.AssetID
+}
+
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"|", "\\|",
+	"<", "&lt;",
+	">", "&gt;",
+	"*", "\\*",
+	"_", "\\_",
+	"`", "\\`",
+	"\r\n", " ",
+	"\n", " ",
+)
+
+// escape makes free text from the report safe to embed in Markdown and in the
+// HTML tags used for the collapsible sections.
+func escape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// escapeCode makes s safe to embed in an inline code span.
+func escapeCode(s string) string {
+	return strings.NewReplacer("`", "'", "\n", " ").Replace(s)
+}
@@ -0,0 +1,176 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestFromIACScanReport(t *testing.T) {
+	report := templates.IACValidationReport{
+		Violations: []templates.Violation{
+			{PolicyID: "P2", AssetID: "asset2", Severity: "LOW"},
+			{PolicyID: "P1", AssetID: "asset1", Severity: "CRITICAL", NextSteps: "Fix it", ViolatedAsset: templates.AssetDetails{AssetType: "storage.googleapis.com/Bucket"}},
+			{PolicyID: "P1", AssetID: "asset3", Severity: "CRITICAL"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		report      templates.IACValidationReport
+		baseline    *templates.IACValidationReport
+		opts        Options
+		wantContain []string
+		wantMissing []string
+	}{
+		{
+			name:        "EmptyReport_Passes",
+			report:      templates.IACValidationReport{},
+			wantContain: []string{"validation-passed-brightgreen", "| **Total** | **0** |"},
+			wantMissing: []string{"<details>"},
+		},
+		{
+			name:   "ViolationsGroupedBySeverityThenPolicy",
+			report: report,
+			wantContain: []string{
+				"validation-3%20violations-red",
+				"| CRITICAL | 2 |\n",
+				"| LOW | 1 |",
+				"<summary><b>CRITICAL</b> <code>P1</code> (2 assets)</summary>",
+				"**Next steps:** Fix it",
+				"- `asset1` (storage.googleapis.com/Bucket)",
+				"<summary><b>LOW</b> <code>P2</code> (1 asset)</summary>",
+			},
+			wantMissing: []string{"Changes since baseline"},
+		},
+		{
+			name:        "AssetsTruncated",
+			report:      report,
+			opts:        Options{MaxAssetsPerPolicy: 1},
+			wantContain: []string{"- `asset1`", "- _… and 1 more_"},
+			wantMissing: []string{"- `asset3`"},
+		},
+		{
+			name:        "PoliciesTruncatedToMaxBytes",
+			report:      report,
+			opts:        Options{MaxBytes: 400},
+			wantContain: []string{"_… and 2 more policies not shown._"},
+			wantMissing: []string{"<code>P1</code>"},
+		},
+		{
+			name:   "BaselineDiff",
+			report: report,
+			baseline: &templates.IACValidationReport{
+				Violations: []templates.Violation{
+					{PolicyID: "P1", AssetID: "asset1", Severity: "CRITICAL"},
+					{PolicyID: "P3", AssetID: "asset4", Severity: "HIGH"},
+				},
+			},
+			wantContain: []string{
+				"**2** new, **1** fixed, **1** unchanged.",
+				"<summary>New violations (2)</summary>",
+				"<summary>Fixed violations (1)</summary>",
+				"- **HIGH** `P3` on `asset4`",
+			},
+		},
+		{
+			name: "UnknownSeverityCounted",
+			report: templates.IACValidationReport{
+				Violations: []templates.Violation{
+					{PolicyID: "P1", AssetID: "a", Severity: "UNKNOWN"},
+				},
+			},
+			wantContain: []string{"| UNKNOWN | 1 |"},
+		},
+		{
+			name:        "UnknownSeverityRowHiddenWhenEmpty",
+			report:      report,
+			wantMissing: []string{"| UNKNOWN |"},
+		},
+		{
+			name: "FreeTextEscaped",
+			report: templates.IACValidationReport{
+				Violations: []templates.Violation{
+					{PolicyID: "P1", AssetID: "a", Severity: "HIGH", ViolatedPolicy: templates.PolicyDetails{Description: "<script>x|y</script>"}},
+				},
+			},
+			wantContain: []string{"&lt;script&gt;x\\|y&lt;/script&gt;"},
+			wantMissing: []string{"<script>"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := FromIACScanReport(test.report, test.baseline, test.opts)
+
+			for _, want := range test.wantContain {
+				if !strings.Contains(got, want) {
+					t.Errorf("FromIACScanReport() = %q, want it to contain %q", got, want)
+				}
+			}
+			for _, missing := range test.wantMissing {
+				if strings.Contains(got, missing) {
+					t.Errorf("FromIACScanReport() = %q, want it not to contain %q", got, missing)
+				}
+			}
+		})
+	}
+}
+
+func TestFromIACScanReport_BaselineDiffNearMaxBytes(t *testing.T) {
+	report := templates.IACValidationReport{}
+	baseline := templates.IACValidationReport{}
+	for i := 0; i < 50; i++ {
+		asset := fmt.Sprintf("//storage.googleapis.com/projects/_/buckets/bucket-with-a-long-name-%d", i)
+		report.Violations = append(report.Violations, templates.Violation{PolicyID: "P1", AssetID: asset + "-new", Severity: "HIGH"})
+		baseline.Violations = append(baseline.Violations, templates.Violation{PolicyID: "P1", AssetID: asset + "-old", Severity: "HIGH"})
+	}
+
+	for _, maxBytes := range []int{800, 1000, 2000, 4000} {
+		got := FromIACScanReport(report, &baseline, Options{MaxBytes: maxBytes, MaxDiffEntries: 100})
+
+		if len(got) > maxBytes {
+			t.Errorf("FromIACScanReport() with MaxBytes %d is %d bytes long: %q", maxBytes, len(got), got)
+		}
+		for _, want := range []string{"**50** new, **50** fixed, **0** unchanged.", "more_\n", "_… and 1 more policies not shown._"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("FromIACScanReport() with MaxBytes %d = %q, want it to contain %q", maxBytes, got, want)
+			}
+		}
+	}
+}
+
+func TestDifference(t *testing.T) {
+	a := []templates.Violation{
+		{PolicyID: "P1", AssetID: "asset1"},
+		{PolicyID: "P1", AssetID: "asset2"},
+	}
+	b := []templates.Violation{
+		{PolicyID: "P1", AssetID: "asset1", Severity: "HIGH"},
+	}
+
+	want := []templates.Violation{{PolicyID: "P1", AssetID: "asset2"}}
+	if diff := cmp.Diff(want, difference(a, b)); diff != "" {
+		t.Errorf("difference() unexpected result (-want, +got): %v", diff)
+	}
+}
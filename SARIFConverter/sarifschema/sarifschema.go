@@ -0,0 +1,369 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package sarifschema validates SARIF documents against the SARIF 2.1.0 JSON
+// schema without network access. It implements the subset of JSON schema
+// draft-04 used by that schema.
+package sarifschema
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaJSON is the OASIS SARIF 2.1.0 schema, errata 01, from
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/errata01/os/schemas/sarif-schema-2.1.0.json
+//
+//go:embed sarif-schema-2.1.0.json
+var schemaJSON []byte
+
+// ValidationError lists where a document does not conform to the schema, each
+// problem starting with the JSON pointer of the offending value.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "document does not match the SARIF 2.1.0 schema:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 any                `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties any                `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	UniqueItems          bool               `json:"uniqueItems"`
+	Minimum              *json.Number       `json:"minimum"`
+	Maximum              *json.Number       `json:"maximum"`
+	Pattern              string             `json:"pattern"`
+	Format               string             `json:"format"`
+	Enum                 []any              `json:"enum"`
+	AnyOf                []*schema          `json:"anyOf"`
+	OneOf                []*schema          `json:"oneOf"`
+	Definitions          map[string]*schema `json:"definitions"`
+}
+
+type validator struct {
+	root     *schema
+	patterns map[string]*regexp.Regexp
+}
+
+// Validate checks that document is a SARIF 2.1.0 log. It returns a
+// *ValidationError when the document is JSON but does not conform.
+func Validate(document []byte) error {
+	root, err := decode[schema](schemaJSON)
+	if err != nil {
+		return fmt.Errorf("decode(schema): %v", err)
+	}
+
+	value, err := decode[any](document)
+	if err != nil {
+		return fmt.Errorf("decode(document): %v", err)
+	}
+
+	v := validator{root: &root, patterns: make(map[string]*regexp.Regexp)}
+	problems := v.validate("", value, v.root)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func decode[T any](data []byte) (T, error) {
+	var v T
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&v)
+	return v, err
+}
+
+func (v *validator) resolve(s *schema) (*schema, error) {
+	for s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/definitions/")
+		if !ok || v.root.Definitions[name] == nil {
+			return nil, fmt.Errorf("unsupported $ref %q", s.Ref)
+		}
+		s = v.root.Definitions[name]
+	}
+	return s, nil
+}
+
+func (v *validator) validate(pointer string, value any, s *schema) []string {
+	s, err := v.resolve(s)
+	if err != nil {
+		return []string{problem(pointer, err.Error())}
+	}
+
+	if !matchesType(value, s.Type) {
+		return []string{problem(pointer, fmt.Sprintf("got %s, want %v", typeOf(value), s.Type))}
+	}
+
+	problems := []string{}
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		problems = append(problems, problem(pointer, fmt.Sprintf("value %s is not one of %s", jsonString(value), jsonString(s.Enum))))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		problems = append(problems, v.validateObject(pointer, value, s)...)
+	case []any:
+		problems = append(problems, v.validateArray(pointer, value, s)...)
+	case string:
+		problems = append(problems, v.validateString(pointer, value, s)...)
+	case json.Number:
+		problems = append(problems, validateNumber(pointer, value, s)...)
+	}
+
+	if len(s.AnyOf) > 0 && v.countMatches(pointer, value, s.AnyOf) == 0 {
+		problems = append(problems, problem(pointer, "does not match any of the allowed schemas"))
+	}
+	if len(s.OneOf) > 0 {
+		if n := v.countMatches(pointer, value, s.OneOf); n != 1 {
+			problems = append(problems, problem(pointer, fmt.Sprintf("matches %d of the schemas, want exactly one", n)))
+		}
+	}
+
+	return problems
+}
+
+func (v *validator) countMatches(pointer string, value any, schemas []*schema) int {
+	n := 0
+	for _, s := range schemas {
+		if len(v.validate(pointer, value, s)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (v *validator) validateObject(pointer string, object map[string]any, s *schema) []string {
+	problems := []string{}
+
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			problems = append(problems, problem(pointer, fmt.Sprintf("missing required property %q", name)))
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := pointer + "/" + escapePointer(name)
+		if property, ok := s.Properties[name]; ok {
+			problems = append(problems, v.validate(child, object[name], property)...)
+			continue
+		}
+
+		switch additional := s.AdditionalProperties.(type) {
+		case bool:
+			if !additional {
+				problems = append(problems, problem(pointer, fmt.Sprintf("unknown property %q", name)))
+			}
+		case map[string]any:
+			additionalSchema, err := remarshal(additional)
+			if err != nil {
+				problems = append(problems, problem(child, err.Error()))
+				continue
+			}
+			problems = append(problems, v.validate(child, object[name], additionalSchema)...)
+		}
+	}
+
+	return problems
+}
+
+func (v *validator) validateArray(pointer string, array []any, s *schema) []string {
+	problems := []string{}
+
+	if s.MinItems != nil && len(array) < *s.MinItems {
+		problems = append(problems, problem(pointer, fmt.Sprintf("has %d items, want at least %d", len(array), *s.MinItems)))
+	}
+
+	if s.UniqueItems {
+		for i := range array {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(array[i], array[j]) {
+					problems = append(problems, problem(pointer, fmt.Sprintf("items %d and %d are equal, want unique items", j, i)))
+				}
+			}
+		}
+	}
+
+	if s.Items != nil {
+		for i, item := range array {
+			problems = append(problems, v.validate(pointer+"/"+strconv.Itoa(i), item, s.Items)...)
+		}
+	}
+
+	return problems
+}
+
+func (v *validator) validateString(pointer, value string, s *schema) []string {
+	problems := []string{}
+
+	if s.Pattern != "" {
+		re, ok := v.patterns[s.Pattern]
+		if !ok {
+			var err error
+			if re, err = regexp.Compile(s.Pattern); err != nil {
+				return []string{problem(pointer, fmt.Sprintf("unsupported pattern %q: %v", s.Pattern, err))}
+			}
+			v.patterns[s.Pattern] = re
+		}
+		if !re.MatchString(value) {
+			problems = append(problems, problem(pointer, fmt.Sprintf("value %q does not match pattern %q", value, s.Pattern)))
+		}
+	}
+
+	if err := checkFormat(value, s.Format); err != nil {
+		problems = append(problems, problem(pointer, fmt.Sprintf("value %q is not a valid %s: %v", value, s.Format, err)))
+	}
+
+	return problems
+}
+
+func validateNumber(pointer string, value json.Number, s *schema) []string {
+	problems := []string{}
+	n, _ := value.Float64()
+
+	if s.Minimum != nil {
+		if minimum, _ := s.Minimum.Float64(); n < minimum {
+			problems = append(problems, problem(pointer, fmt.Sprintf("value %s is less than the minimum %s", value, *s.Minimum)))
+		}
+	}
+	if s.Maximum != nil {
+		if maximum, _ := s.Maximum.Float64(); n > maximum {
+			problems = append(problems, problem(pointer, fmt.Sprintf("value %s is greater than the maximum %s", value, *s.Maximum)))
+		}
+	}
+
+	return problems
+}
+
+func checkFormat(value, format string) error {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err
+	case "uri":
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		if !u.IsAbs() {
+			return fmt.Errorf("not an absolute URI")
+		}
+	case "uri-reference":
+		_, err := url.Parse(value)
+		return err
+	}
+	return nil
+}
+
+func matchesType(value any, schemaType any) bool {
+	switch t := schemaType.(type) {
+	case nil:
+		return true
+	case string:
+		return isType(value, t)
+	case []any:
+		for _, name := range t {
+			if name, ok := name.(string); ok && isType(value, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isType(value any, name string) bool {
+	if name == "integer" {
+		n, ok := value.(json.Number)
+		return ok && !strings.ContainsAny(n.String(), ".eE")
+	}
+	return typeOf(value) == name
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func inEnum(value any, enum []any) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func remarshal(value any) (*schema, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	s, err := decode[schema](data)
+	return &s, err
+}
+
+// problem prefixes message with the JSON pointer of the value, "#" being the
+// whole document.
+func problem(pointer, message string) string {
+	return fmt.Sprintf("#%s: %s", pointer, message)
+}
+
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
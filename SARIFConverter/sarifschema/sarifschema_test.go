@@ -0,0 +1,181 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sarifschema
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/converter"
+	"github.com/google/gcp-scc-iac-validation-utils/SARIFConverter/terraform"
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		document     string
+		wantProblems []string
+		wantError    bool
+	}{
+		{
+			name:     "MinimalLog_Succeeds",
+			document: `{"version": "2.1.0", "runs": []}`,
+		},
+		{
+			name: "InvalidLog_ReportsPointers",
+			document: `{
+				"version": "2.0.0",
+				"runs": [{
+					"tool": {"driver": {"name": "tool", "informationUri": "not a uri"}},
+					"results": [{"message": {"text": "m"}, "level": "fatal", "ruleIndex": -2, "unknown": 1}],
+					"properties": {"a/b": {"any": "thing"}}
+				}]
+			}`,
+			wantProblems: []string{
+				`#/runs/0/results/0/level: value "fatal" is not one of ["none","note","warning","error"]`,
+				`#/runs/0/results/0/ruleIndex: value -2 is less than the minimum -1`,
+				`#/runs/0/results/0: unknown property "unknown"`,
+				`#/runs/0/tool/driver/informationUri: value "not a uri" is not a valid uri: not an absolute URI`,
+				`#/version: value "2.0.0" is not one of ["2.1.0"]`,
+			},
+		},
+		{
+			name:         "MissingRequired_Failure",
+			document:     `{"runs": [{"tool": {}}]}`,
+			wantProblems: []string{`#: missing required property "version"`, `#/runs/0/tool: missing required property "driver"`},
+		},
+		{
+			name:         "WrongType_Failure",
+			document:     `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": 1}}, "invocations": [{"executionSuccessful": "yes"}]}]}`,
+			wantProblems: []string{`#/runs/0/invocations/0/executionSuccessful: got string, want boolean`, `#/runs/0/tool/driver/name: got number, want string`},
+		},
+		{
+			name:      "NotJSON_Failure",
+			document:  `not json`,
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate([]byte(test.document))
+
+			var validationError *ValidationError
+			if errors.As(err, &validationError) {
+				if diff := cmp.Diff(test.wantProblems, validationError.Problems); diff != "" {
+					t.Errorf("Validate() unexpected problems (-want, +got): %v", diff)
+				}
+				return
+			}
+
+			if (err != nil) != test.wantError {
+				t.Errorf("Expected error: %v, got: %v", test.wantError, err)
+			}
+			if test.wantProblems != nil {
+				t.Errorf("Validate() = %v, want problems %v", err, test.wantProblems)
+			}
+		})
+	}
+}
+
+// TestValidate_ConverterOutput checks that the converter output conforms to
+// the schema with every optional feature enabled.
+func TestValidate_ConverterOutput(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"google_storage_bucket\" \"b\" {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := terraform.IndexSourceDir(dir)
+	if err != nil {
+		t.Fatalf("terraform.IndexSourceDir() failed: %v", err)
+	}
+
+	previous := templates.Responses{
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{{PolicyID: "P9", AssetID: "fixed", Severity: "LOW"}},
+		},
+	}
+	baseline, err := converter.FromIACScanReport(previous, converter.Options{})
+	if err != nil {
+		t.Fatalf("FromIACScanReport(previous) failed: %v", err)
+	}
+
+	response := templates.Responses{
+		Name:       "organizations/1/locations/global/reports/abc",
+		CreateTime: "2024-05-01T10:00:00.123456Z",
+		UpdateTime: "2024-05-01T10:02:30Z",
+		IacValidationReport: templates.IACValidationReport{
+			Note: "Some resources were not evaluated.",
+			Violations: []templates.Violation{
+				{
+					PolicyID:  "P1",
+					AssetID:   "google_storage_bucket.b",
+					Severity:  "HIGH",
+					NextSteps: "Enable uniform bucket-level access.",
+					ViolatedPosture: templates.PostureDetails{
+						Posture:           "organizations/1/locations/global/postures/p1",
+						PostureRevisionID: "r1",
+						PolicySet:         "storage",
+					},
+					ViolatedPolicy: templates.PolicyDetails{
+						ConstraintType:      "ORG_POLICY",
+						Constraint:          `{"orgPolicyConstraint":{"cannedConstraintId":"storage.uniformBucketLevelAccess"}}`,
+						ComplianceStandards: []string{"CIS 2.0 5.2", "HIPAA"},
+						Description:         "Buckets must use uniform bucket-level access.",
+					},
+				},
+				{PolicyID: "P2", AssetID: "other", Severity: "SEVERITY_UNSPECIFIED"},
+			},
+		},
+	}
+	opts := converter.Options{
+		Levels:   converter.DEFAULT_LEVELS,
+		Sources:  sources,
+		Waivers:  []converter.Waiver{{PolicyID: "P2", Justification: "Accepted"}},
+		Now:      time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Baseline: &baseline,
+		Category: "network",
+	}
+	opts.SeverityPolicy.Mode = "unknown"
+
+	output, err := converter.FromIACScanReport(response, opts)
+	if err != nil {
+		t.Fatalf("FromIACScanReport() failed: %v", err)
+	}
+	output = converter.LimitResults(output, 0, 1)
+
+	for name, report := range map[string]templates.SarifOutput{
+		"Rules":             output,
+		"PostureExtensions": converter.PostureExtensions(output),
+	} {
+		document, err := json.Marshal(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := Validate(document); err != nil {
+			t.Errorf("Validate() failed for the converter output with %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package tabular flattens the IaC SCC scan report into one row per violation
+// and writes it as CSV or newline-delimited JSON, e.g. for spreadsheets and
+// BigQuery.
+package tabular
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// COMPLIANCE_STANDARDS_SEPARATOR joins the compliance standards of a violation
+// into a single CSV cell. NDJSON keeps them as an array.
+const COMPLIANCE_STANDARDS_SEPARATOR = ";"
+
+// Row is a single violation together with the report level fields. The JSON
+// names double as CSV header and BigQuery column names.
+type Row struct {
+	ReportName                      string   `json:"report_name,omitempty"`
+	ReportCreateTime                string   `json:"report_create_time,omitempty"`
+	ReportUpdateTime                string   `json:"report_update_time,omitempty"`
+	AssetID                         string   `json:"asset_id,omitempty"`
+	PolicyID                        string   `json:"policy_id,omitempty"`
+	Severity                        string   `json:"severity,omitempty"`
+	NextSteps                       string   `json:"next_steps,omitempty"`
+	PostureDeployment               string   `json:"posture_deployment,omitempty"`
+	PostureDeploymentTargetResource string   `json:"posture_deployment_target_resource,omitempty"`
+	Posture                         string   `json:"posture,omitempty"`
+	PostureRevisionID               string   `json:"posture_revision_id,omitempty"`
+	PolicySet                       string   `json:"policy_set,omitempty"`
+	Constraint                      string   `json:"constraint,omitempty"`
+	ConstraintType                  string   `json:"constraint_type,omitempty"`
+	ComplianceStandards             []string `json:"compliance_standards,omitempty"`
+	Description                     string   `json:"description,omitempty"`
+	Asset                           string   `json:"asset,omitempty"`
+	AssetType                       string   `json:"asset_type,omitempty"`
+}
+
+type column struct {
+	name        string
+	bqType      string
+	mode        string
+	description string
+	value       func(Row) string
+}
+
+// columns lists the fields of Row in output order. The CSV writer and the
+// BigQuery schema are both generated from it.
+var columns = []column{
+	{"report_name", "STRING", "NULLABLE", "Resource name of the IaC validation report.", func(r Row) string { return r.ReportName }},
+	{"report_create_time", "TIMESTAMP", "NULLABLE", "Time the report was created.", func(r Row) string { return r.ReportCreateTime }},
+	{"report_update_time", "TIMESTAMP", "NULLABLE", "Time the report was last updated.", func(r Row) string { return r.ReportUpdateTime }},
+	{"asset_id", "STRING", "NULLABLE", "Identifier of the violating asset.", func(r Row) string { return r.AssetID }},
+	{"policy_id", "STRING", "NULLABLE", "Identifier of the violated policy.", func(r Row) string { return r.PolicyID }},
+	{"severity", "STRING", "NULLABLE", "Severity of the violation.", func(r Row) string { return r.Severity }},
+	{"next_steps", "STRING", "NULLABLE", "Recommended remediation.", func(r Row) string { return r.NextSteps }},
+	{"posture_deployment", "STRING", "NULLABLE", "Posture deployment the policy belongs to.", func(r Row) string { return r.PostureDeployment }},
+	{"posture_deployment_target_resource", "STRING", "NULLABLE", "Resource the posture deployment targets.", func(r Row) string { return r.PostureDeploymentTargetResource }},
+	{"posture", "STRING", "NULLABLE", "Posture the policy belongs to.", func(r Row) string { return r.Posture }},
+	{"posture_revision_id", "STRING", "NULLABLE", "Revision of the posture.", func(r Row) string { return r.PostureRevisionID }},
+	{"policy_set", "STRING", "NULLABLE", "Policy set the policy belongs to.", func(r Row) string { return r.PolicySet }},
+	{"constraint", "STRING", "NULLABLE", "Constraint enforced by the policy.", func(r Row) string { return r.Constraint }},
+	{"constraint_type", "STRING", "NULLABLE", "Type of the constraint.", func(r Row) string { return r.ConstraintType }},
+	{"compliance_standards", "STRING", "REPEATED", "Compliance standards the policy maps to.", func(r Row) string {
+		return strings.Join(r.ComplianceStandards, COMPLIANCE_STANDARDS_SEPARATOR)
+	}},
+	{"description", "STRING", "NULLABLE", "Description of the policy.", func(r Row) string { return r.Description }},
+	{"asset", "STRING", "NULLABLE", "Name of the violating asset.", func(r Row) string { return r.Asset }},
+	{"asset_type", "STRING", "NULLABLE", "Type of the violating asset.", func(r Row) string { return r.AssetType }},
+}
+
+// SchemaField is a column of a BigQuery table schema as accepted by
+// `bq mk --table` and `bq load --schema`.
+type SchemaField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Mode        string `json:"mode"`
+	Description string `json:"description,omitempty"`
+}
+
+// FromIACScanReport flattens every violation of response into a Row.
+func FromIACScanReport(response templates.Responses) []Row {
+	rows := []Row{}
+
+	for _, v := range response.IacValidationReport.Violations {
+		rows = append(rows, Row{
+			ReportName:                      response.Name,
+			ReportCreateTime:                response.CreateTime,
+			ReportUpdateTime:                response.UpdateTime,
+			AssetID:                         v.AssetID,
+			PolicyID:                        v.PolicyID,
+			Severity:                        v.Severity,
+			NextSteps:                       v.NextSteps,
+			PostureDeployment:               v.ViolatedPosture.PostureDeployment,
+			PostureDeploymentTargetResource: v.ViolatedPosture.PostureDeploymentTargetResource,
+			Posture:                         v.ViolatedPosture.Posture,
+			PostureRevisionID:               v.ViolatedPosture.PostureRevisionID,
+			PolicySet:                       v.ViolatedPosture.PolicySet,
+			Constraint:                      v.ViolatedPolicy.Constraint,
+			ConstraintType:                  v.ViolatedPolicy.ConstraintType,
+			ComplianceStandards:             v.ViolatedPolicy.ComplianceStandards,
+			Description:                     v.ViolatedPolicy.Description,
+			Asset:                           v.ViolatedAsset.Asset,
+			AssetType:                       v.ViolatedAsset.AssetType,
+		})
+	}
+
+	return rows
+}
+
+// WriteCSV writes the rows of response as CSV with a header line.
+func WriteCSV(w io.Writer, response templates.Responses) error {
+	csvWriter := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("csvWriter.Write: %v", err)
+	}
+
+	for _, row := range FromIACScanReport(response) {
+		record := make([]string, len(columns))
+		for i, c := range columns {
+			record[i] = c.value(row)
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return fmt.Errorf("csvWriter.Write: %v", err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("csvWriter.Flush: %v", err)
+	}
+
+	return nil
+}
+
+// WriteNDJSON writes the rows of response as newline-delimited JSON, one
+// violation per line.
+func WriteNDJSON(w io.Writer, response templates.Responses) error {
+	encoder := json.NewEncoder(w)
+
+	for _, row := range FromIACScanReport(response) {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("encoder.Encode: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// BigQuerySchema returns the schema of the table the NDJSON output can be
+// loaded into.
+func BigQuerySchema() []SchemaField {
+	schema := make([]SchemaField, len(columns))
+	for i, c := range columns {
+		schema[i] = SchemaField{Name: c.name, Type: c.bqType, Mode: c.mode, Description: c.description}
+	}
+	return schema
+}
+
+// WriteBigQuerySchema writes BigQuerySchema as a JSON schema file.
+func WriteBigQuerySchema(w io.Writer) error {
+	schemaJSON, err := json.MarshalIndent(BigQuerySchema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %v", err)
+	}
+
+	if _, err := w.Write(append(schemaJSON, '\n')); err != nil {
+		return fmt.Errorf("w.Write: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tabular
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+var response = templates.Responses{
+	Name:       "reports/abc",
+	CreateTime: "2024-05-01T10:00:00Z",
+	IacValidationReport: templates.IACValidationReport{
+		Violations: []templates.Violation{
+			{
+				AssetID:         "asset1",
+				PolicyID:        "P1",
+				Severity:        "HIGH",
+				NextSteps:       "Fix, then rerun",
+				ViolatedPosture: templates.PostureDetails{Posture: "Posture 1", PostureRevisionID: "Rev 1"},
+				ViolatedPolicy:  templates.PolicyDetails{Constraint: "C1", ComplianceStandards: []string{"CIS 2.0 1.1", "NIST 800-53 AC-3"}},
+				ViolatedAsset:   templates.AssetDetails{Asset: "a1", AssetType: "storage.googleapis.com/Bucket"},
+			},
+			{
+				AssetID:  "asset2",
+				PolicyID: "P2",
+				Severity: "LOW",
+			},
+		},
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCSV(&b, response); err != nil {
+		t.Fatalf("WriteCSV() failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("WriteCSV() got %d lines, want 3: %q", len(lines), b.String())
+	}
+
+	wantHeader := "report_name,report_create_time,report_update_time,asset_id,policy_id,severity,next_steps,posture_deployment,posture_deployment_target_resource,posture,posture_revision_id,policy_set,constraint,constraint_type,compliance_standards,description,asset,asset_type"
+	if diff := cmp.Diff(wantHeader, lines[0]); diff != "" {
+		t.Errorf("Unexpected header (-want, +got): %v", diff)
+	}
+
+	wantRow := `reports/abc,2024-05-01T10:00:00Z,,asset1,P1,HIGH,"Fix, then rerun",,,Posture 1,Rev 1,,C1,,CIS 2.0 1.1;NIST 800-53 AC-3,,a1,storage.googleapis.com/Bucket`
+	if diff := cmp.Diff(wantRow, lines[1]); diff != "" {
+		t.Errorf("Unexpected row (-want, +got): %v", diff)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteNDJSON(&b, response); err != nil {
+		t.Fatalf("WriteNDJSON() failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("WriteNDJSON() got %d lines, want 2: %q", len(lines), b.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) failed: %v", lines[0], err)
+	}
+	if diff := cmp.Diff([]any{"CIS 2.0 1.1", "NIST 800-53 AC-3"}, got["compliance_standards"]); diff != "" {
+		t.Errorf("Unexpected compliance_standards (-want, +got): %v", diff)
+	}
+	if got["report_name"] != "reports/abc" {
+		t.Errorf("Unexpected report_name: %v", got["report_name"])
+	}
+}
+
+// TestBigQuerySchemaMatchesRow guards against the schema, the CSV columns and
+// the NDJSON field names drifting apart.
+func TestBigQuerySchemaMatchesRow(t *testing.T) {
+	rowType := reflect.TypeOf(Row{})
+
+	var jsonNames []string
+	for i := 0; i < rowType.NumField(); i++ {
+		jsonNames = append(jsonNames, strings.Split(rowType.Field(i).Tag.Get("json"), ",")[0])
+	}
+
+	var schemaNames []string
+	for _, field := range BigQuerySchema() {
+		schemaNames = append(schemaNames, field.Name)
+	}
+
+	if diff := cmp.Diff(jsonNames, schemaNames); diff != "" {
+		t.Errorf("Schema does not match Row (-row, +schema): %v", diff)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package terraform
+
+import (
+	"regexp"
+	"strings"
+)
+
+// INDENT is the indentation added per nesting level, as written by
+// terraform fmt.
+const INDENT = "  "
+
+var (
+	attributePattern = regexp.MustCompile(`^\s*([A-Za-z_][\w-]*)\s*=`)
+	blockPattern     = regexp.MustCompile(`^\s*([A-Za-z_][\w-]*)(\s+"[^"]*")*\s*\{`)
+	indentPattern    = regexp.MustCompile(`^\s*`)
+)
+
+// Change replaces the lines from StartLine up to, but excluding, EndLine of
+// the file at URI with Text. When both are equal Text is inserted before
+// StartLine. Text ends with a newline unless it is empty.
+type Change struct {
+	URI       string
+	StartLine int
+	EndLine   int
+	Text      string
+}
+
+// body is the content of a block, with 0-based line numbers.
+type body struct {
+	// first and last are the lines of the header and the closing brace.
+	first, last int
+	indent      string
+	// attributes holds the first and last line of every attribute.
+	attributes map[string][2]int
+	blocks     map[string][]body
+}
+
+// SetAttribute returns the change that sets the attribute at path, e.g.
+// settings.ip_configuration.ipv4_enabled, of the resource block to value, an
+// HCL expression. Blocks along the path must exist and be unique. It returns
+// false when the change is not possible or the attribute already has value.
+func (idx *Index) SetAttribute(location Location, path []string, value string) (Change, bool) {
+	parent, lines, ok := idx.findBody(location, path[:len(path)-1])
+	if !ok {
+		return Change{}, false
+	}
+
+	name := path[len(path)-1]
+	text := parent.indent + INDENT + name + " = " + value + "\n"
+
+	if attribute, ok := parent.attributes[name]; ok {
+		if attribute[0] == attribute[1] && attributeValue(lines[attribute[0]]) == value {
+			return Change{}, false
+		}
+		return Change{URI: location.URI, StartLine: attribute[0] + 1, EndLine: attribute[1] + 2, Text: text}, true
+	}
+	if _, ok := parent.blocks[name]; ok {
+		return Change{}, false
+	}
+
+	return Change{URI: location.URI, StartLine: parent.last + 1, EndLine: parent.last + 1, Text: text}, true
+}
+
+// AddBlock returns the change that appends a block at path with the given
+// attribute lines, e.g. "enable_secure_boot = true", to the resource block.
+// It returns false when the block already exists or its parent does not.
+func (idx *Index) AddBlock(location Location, path []string, attributes []string) (Change, bool) {
+	parent, _, ok := idx.findBody(location, path[:len(path)-1])
+	if !ok {
+		return Change{}, false
+	}
+
+	name := path[len(path)-1]
+	if _, ok := parent.blocks[name]; ok {
+		return Change{}, false
+	}
+	if _, ok := parent.attributes[name]; ok {
+		return Change{}, false
+	}
+
+	indent := parent.indent + INDENT
+	var text strings.Builder
+	text.WriteString(indent + name + " {\n")
+	for _, attribute := range attributes {
+		text.WriteString(indent + INDENT + attribute + "\n")
+	}
+	text.WriteString(indent + "}\n")
+
+	return Change{URI: location.URI, StartLine: parent.last + 1, EndLine: parent.last + 1, Text: text.String()}, true
+}
+
+// RemoveBlocks returns the changes that delete all blocks at path, e.g.
+// network_interface.access_config, of the resource block. It returns false
+// when there are none.
+func (idx *Index) RemoveBlocks(location Location, path []string) ([]Change, bool) {
+	parents, ok := idx.findBodies(location, path[:len(path)-1])
+	if !ok {
+		return nil, false
+	}
+
+	changes := []Change{}
+	for _, parent := range parents {
+		for _, block := range parent.blocks[path[len(path)-1]] {
+			changes = append(changes, Change{URI: location.URI, StartLine: block.first + 1, EndLine: block.last + 2})
+		}
+	}
+
+	return changes, len(changes) > 0
+}
+
+// findBody returns the unique block at path below the resource block. Blocks
+// on a single line are not returned, as nothing can be inserted into them.
+func (idx *Index) findBody(location Location, path []string) (body, []string, bool) {
+	bodies, ok := idx.findBodies(location, path)
+	if !ok || len(bodies) != 1 || bodies[0].first == bodies[0].last {
+		return body{}, nil, false
+	}
+	return bodies[0], idx.files[location.URI], true
+}
+
+// findBodies returns all blocks at path below the resource block, or the
+// resource block itself for an empty path.
+func (idx *Index) findBodies(location Location, path []string) ([]body, bool) {
+	lines, ok := idx.files[location.URI]
+	if !ok || location.EndLine > len(lines) {
+		return nil, false
+	}
+
+	bodies := []body{parseBody(lines, location.StartLine-1, location.EndLine-1)}
+	for _, name := range path {
+		children := []body{}
+		for _, b := range bodies {
+			children = append(children, b.blocks[name]...)
+		}
+		bodies = children
+	}
+
+	return bodies, len(bodies) > 0
+}
+
+// parseBody parses the attributes and nested blocks of the block from line
+// first, its header, to line last, its closing brace.
+func parseBody(lines []string, first, last int) body {
+	b := body{
+		first:      first,
+		last:       last,
+		indent:     indentPattern.FindString(lines[first]),
+		attributes: make(map[string][2]int),
+		blocks:     make(map[string][]body),
+	}
+
+	var s scanner
+	for i := first + 1; i < last; i++ {
+		if s.depth != 0 || s.inBlockComment || s.heredoc != "" {
+			s.scanLine(lines[i])
+			continue
+		}
+
+		start := i
+		s.scanLine(lines[i])
+		for (s.depth > 0 || s.heredoc != "") && i+1 < last {
+			i++
+			s.scanLine(lines[i])
+		}
+
+		if m := attributePattern.FindStringSubmatch(lines[start]); m != nil {
+			b.attributes[m[1]] = [2]int{start, i}
+		} else if m := blockPattern.FindStringSubmatch(lines[start]); m != nil {
+			b.blocks[m[1]] = append(b.blocks[m[1]], parseBody(lines, start, i))
+		}
+	}
+
+	return b
+}
+
+// attributeValue returns the expression of a single line attribute without
+// trailing comment.
+func attributeValue(line string) string {
+	_, value, _ := strings.Cut(line, "=")
+	for _, comment := range []string{"#", "//"} {
+		if i := strings.Index(value, comment); i >= 0 && !strings.Contains(value[:i], `"`) {
+			value = value[:i]
+		}
+	}
+	return strings.TrimSpace(value)
+}
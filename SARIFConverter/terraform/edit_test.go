@@ -0,0 +1,173 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package terraform
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const editTF = `resource "google_storage_bucket" "logs" {
+  name                        = "my-logs"
+  uniform_bucket_level_access = false # legacy ACLs
+  labels = {
+    team = "security"
+  }
+}
+
+resource "google_compute_instance" "vm" {
+  network_interface {
+    network = "default"
+    access_config {}
+  }
+  network_interface {
+    network = "internal"
+    access_config {
+      nat_ip = "1.2.3.4"
+    }
+  }
+  shielded_instance_config {}
+}
+
+resource "google_sql_database_instance" "db" {
+  settings {
+    ip_configuration {
+      ipv4_enabled = false
+    }
+  }
+}
+`
+
+func TestEdits(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), editTF)
+
+	idx, err := IndexSourceDir(dir)
+	if err != nil {
+		t.Fatalf("IndexSourceDir() failed: %v", err)
+	}
+	bucket, _ := idx.Lookup("google_storage_bucket.logs")
+	vm, _ := idx.Lookup("google_compute_instance.vm")
+	db, _ := idx.Lookup("google_sql_database_instance.db")
+
+	t.Run("SetAttribute", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			location Location
+			path     []string
+			value    string
+			want     Change
+			wantOK   bool
+		}{
+			{
+				name:     "ReplacesAttribute",
+				location: bucket,
+				path:     []string{"uniform_bucket_level_access"},
+				value:    "true",
+				want:     Change{URI: "main.tf", StartLine: 3, EndLine: 4, Text: "  uniform_bucket_level_access = true\n"},
+				wantOK:   true,
+			},
+			{
+				name:     "ReplacesMultiLineAttribute",
+				location: bucket,
+				path:     []string{"labels"},
+				value:    "{}",
+				want:     Change{URI: "main.tf", StartLine: 4, EndLine: 7, Text: "  labels = {}\n"},
+				wantOK:   true,
+			},
+			{
+				name:     "InsertsAttribute",
+				location: bucket,
+				path:     []string{"public_access_prevention"},
+				value:    `"enforced"`,
+				want:     Change{URI: "main.tf", StartLine: 7, EndLine: 7, Text: "  public_access_prevention = \"enforced\"\n"},
+				wantOK:   true,
+			},
+			{
+				name:     "AlreadySet",
+				location: db,
+				path:     []string{"settings", "ip_configuration", "ipv4_enabled"},
+				value:    "false",
+			},
+			{
+				name:     "MissingParentBlock",
+				location: bucket,
+				path:     []string{"encryption", "default_kms_key_name"},
+				value:    `"key"`,
+			},
+			{
+				name:     "AmbiguousParentBlock",
+				location: vm,
+				path:     []string{"network_interface", "network"},
+				value:    `"other"`,
+			},
+			{
+				name:     "SingleLineParentBlock",
+				location: vm,
+				path:     []string{"shielded_instance_config", "enable_vtpm"},
+				value:    "true",
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				got, ok := idx.SetAttribute(test.location, test.path, test.value)
+				if ok != test.wantOK {
+					t.Errorf("SetAttribute(%v) ok = %v, want %v", test.path, ok, test.wantOK)
+				}
+				if diff := cmp.Diff(test.want, got); diff != "" {
+					t.Errorf("SetAttribute(%v) unexpected result (-want, +got): %v", test.path, diff)
+				}
+			})
+		}
+	})
+
+	t.Run("AddBlock", func(t *testing.T) {
+		got, ok := idx.AddBlock(bucket, []string{"encryption"}, []string{`default_kms_key_name = "key"`})
+		want := Change{URI: "main.tf", StartLine: 7, EndLine: 7, Text: "  encryption {\n    default_kms_key_name = \"key\"\n  }\n"}
+		if !ok {
+			t.Errorf("AddBlock() ok = false, want true")
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("AddBlock() unexpected result (-want, +got): %v", diff)
+		}
+
+		if _, ok := idx.AddBlock(vm, []string{"shielded_instance_config"}, nil); ok {
+			t.Errorf("AddBlock() of existing block ok = true, want false")
+		}
+	})
+
+	t.Run("RemoveBlocks", func(t *testing.T) {
+		got, ok := idx.RemoveBlocks(vm, []string{"network_interface", "access_config"})
+		want := []Change{
+			{URI: "main.tf", StartLine: 12, EndLine: 13},
+			{URI: "main.tf", StartLine: 16, EndLine: 19},
+		}
+		if !ok {
+			t.Errorf("RemoveBlocks() ok = false, want true")
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("RemoveBlocks() unexpected result (-want, +got): %v", diff)
+		}
+
+		if _, ok := idx.RemoveBlocks(bucket, []string{"network_interface", "access_config"}); ok {
+			t.Errorf("RemoveBlocks() without blocks ok = true, want false")
+		}
+	})
+}
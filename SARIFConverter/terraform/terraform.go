@@ -0,0 +1,304 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package terraform resolves the assets of the IaC SCC scan report to the
+// resource blocks of the Terraform configuration that declared them.
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SRCROOT is the uriBaseId of the locations, it stands for the source
+// directory the index was built from.
+const SRCROOT = "%SRCROOT%"
+
+var (
+	resourcePattern = regexp.MustCompile(`^\s*resource\s+"([^"]+)"\s+"([^"]+)"\s*\{`)
+	// instanceKeyPattern matches the count and for_each keys of an address,
+	// e.g. [0] or ["key"].
+	instanceKeyPattern = regexp.MustCompile(`\[[^\]]*\]`)
+)
+
+// Location is the line range of a resource block. URI is slash separated and
+// relative to the source directory.
+type Location struct {
+	// Address is the address of the block, without module path, e.g.
+	// google_storage_bucket.logs.
+	Address   string
+	URI       string
+	StartLine int
+	EndLine   int
+}
+
+// Index maps Terraform resource addresses, and the names and IDs the plan
+// assigns to them, to their resource blocks.
+type Index struct {
+	// RootDir is the absolute path of the source directory.
+	RootDir string
+
+	resources map[string][]Location
+	// files holds the lines of the indexed files, keyed by URI.
+	files map[string][]string
+	// aliases maps asset names and IDs from the plan to resource addresses.
+	// Names shared by several resources map to "" as they are ambiguous.
+	aliases map[string]string
+}
+
+type planModule struct {
+	Resources []struct {
+		Address string         `json:"address"`
+		Mode    string         `json:"mode"`
+		Type    string         `json:"type"`
+		Name    string         `json:"name"`
+		Values  map[string]any `json:"values"`
+	} `json:"resources"`
+	ChildModules []planModule `json:"child_modules"`
+}
+
+type plan struct {
+	PlannedValues struct {
+		RootModule planModule `json:"root_module"`
+	} `json:"planned_values"`
+}
+
+// IndexSourceDir indexes the resource blocks of all .tf files below dir.
+func IndexSourceDir(dir string) (*Index, error) {
+	rootDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Abs: %v", err)
+	}
+
+	idx := &Index{
+		RootDir:   rootDir,
+		resources: make(map[string][]Location),
+		files:     make(map[string][]string),
+		aliases:   make(map[string]string),
+	}
+
+	err = filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != rootDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".tf" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return err
+		}
+
+		uri := filepath.ToSlash(rel)
+		idx.files[uri] = strings.Split(string(content), "\n")
+		for address, location := range parseResources(string(content), uri) {
+			idx.resources[address] = append(idx.resources[address], location)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("filepath.WalkDir(%s): %v", dir, err)
+	}
+
+	return idx, nil
+}
+
+// AddPlan reads the JSON output of `terraform show -json` and registers the
+// name, ID and self link of every planned resource as an alias of its
+// address, so that assets identified by their cloud name can be resolved.
+func (idx *Index) AddPlan(planPath string) error {
+	content, err := os.ReadFile(planPath)
+	if err != nil {
+		return fmt.Errorf("os.ReadFile(%s): %v", planPath, err)
+	}
+
+	var p plan
+	if err := json.Unmarshal(content, &p); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	idx.addPlanModule(p.PlannedValues.RootModule)
+	return nil
+}
+
+func (idx *Index) addPlanModule(module planModule) {
+	for _, resource := range module.Resources {
+		if resource.Mode == "data" {
+			continue
+		}
+
+		address := resource.Type + "." + resource.Name
+		for _, key := range []string{"name", "id", "self_link"} {
+			if value, ok := resource.Values[key].(string); ok && value != "" {
+				idx.addAlias(value, address)
+			}
+		}
+		idx.addAlias(resource.Address, address)
+	}
+
+	for _, child := range module.ChildModules {
+		idx.addPlanModule(child)
+	}
+}
+
+func (idx *Index) addAlias(alias, address string) {
+	if existing, ok := idx.aliases[alias]; ok && existing != address {
+		idx.aliases[alias] = ""
+		return
+	}
+	idx.aliases[alias] = address
+}
+
+// Lookup returns the resource block of the first key that resolves to exactly
+// one block. Keys can be Terraform addresses, optionally with module path and
+// instance keys, or names and IDs registered by AddPlan.
+func (idx *Index) Lookup(keys ...string) (Location, bool) {
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		if location, ok := idx.lookupAddress(key); ok {
+			return location, true
+		}
+
+		candidates := []string{key}
+		if i := strings.LastIndex(key, "/"); i >= 0 && i < len(key)-1 {
+			candidates = append(candidates, key[i+1:])
+		}
+		for _, candidate := range candidates {
+			if address := idx.aliases[candidate]; address != "" {
+				if location, ok := idx.lookupAddress(address); ok {
+					return location, true
+				}
+			}
+		}
+	}
+
+	return Location{}, false
+}
+
+func (idx *Index) lookupAddress(address string) (Location, bool) {
+	locations := idx.resources[resourceAddress(address)]
+	if len(locations) != 1 {
+		return Location{}, false
+	}
+	return locations[0], true
+}
+
+// resourceAddress strips the module path and instance keys from address,
+// e.g. module.net.google_compute_network.vpc[0] becomes
+// google_compute_network.vpc.
+func resourceAddress(address string) string {
+	parts := strings.Split(instanceKeyPattern.ReplaceAllString(address, ""), ".")
+	if len(parts) < 2 {
+		return address
+	}
+	return parts[len(parts)-2] + "." + parts[len(parts)-1]
+}
+
+// parseResources returns the line ranges of the resource blocks in content,
+// keyed by address.
+func parseResources(content, uri string) map[string]Location {
+	resources := make(map[string]Location)
+	lines := strings.Split(content, "\n")
+
+	var s scanner
+	for i := 0; i < len(lines); i++ {
+		if s.depth == 0 && !s.inBlockComment && s.heredoc == "" {
+			if m := resourcePattern.FindStringSubmatch(lines[i]); m != nil {
+				start := i
+				s.scanLine(lines[i])
+				for s.depth > 0 && i+1 < len(lines) {
+					i++
+					s.scanLine(lines[i])
+				}
+				address := m[1] + "." + m[2]
+				resources[address] = Location{Address: address, URI: uri, StartLine: start + 1, EndLine: i + 1}
+				continue
+			}
+		}
+		s.scanLine(lines[i])
+	}
+
+	return resources
+}
+
+// scanner tracks the brace depth of HCL code, ignoring braces in strings,
+// comments and heredocs.
+type scanner struct {
+	depth          int
+	inBlockComment bool
+	heredoc        string
+}
+
+func (s *scanner) scanLine(line string) {
+	if s.heredoc != "" {
+		if strings.TrimSpace(line) == s.heredoc {
+			s.heredoc = ""
+		}
+		return
+	}
+
+	inString := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.inBlockComment:
+			if strings.HasPrefix(line[i:], "*/") {
+				s.inBlockComment = false
+				i++
+			}
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '#' || strings.HasPrefix(line[i:], "//"):
+			return
+		case strings.HasPrefix(line[i:], "/*"):
+			s.inBlockComment = true
+			i++
+		case strings.HasPrefix(line[i:], "<<"):
+			s.heredoc = strings.TrimSpace(strings.TrimPrefix(line[i+2:], "-"))
+			return
+		case c == '{':
+			s.depth++
+		case c == '}':
+			s.depth--
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const mainTF = `provider "google" {}
+
+resource "google_storage_bucket" "logs" {
+  name     = "my-logs"
+  location = "EU"
+  labels = {
+    team = "sec{urity" # }
+  }
+}
+
+/* resource "google_storage_bucket" "commented" {
+} */
+resource "google_compute_instance" "vm" {
+  metadata_startup_script = <<-EOT
+    echo "}"
+  EOT
+  // }
+}
+`
+
+const modulePlan = `{
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {"address": "google_storage_bucket.logs", "mode": "managed", "type": "google_storage_bucket", "name": "logs", "values": {"name": "my-logs"}},
+        {"address": "data.google_project.p", "mode": "data", "type": "google_project", "name": "p", "values": {"name": "my-vm"}}
+      ],
+      "child_modules": [
+        {"resources": [
+          {"address": "module.net.google_compute_network.vpc[0]", "mode": "managed", "type": "google_compute_network", "name": "vpc", "values": {"name": "shared"}},
+          {"address": "module.net.google_compute_instance.vm", "mode": "managed", "type": "google_compute_instance", "name": "vm", "values": {"name": "my-vm"}}
+        ]}
+      ]
+    }
+  }
+}`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	want := map[string]Location{
+		"google_storage_bucket.logs": {Address: "google_storage_bucket.logs", URI: "main.tf", StartLine: 3, EndLine: 9},
+		"google_compute_instance.vm": {Address: "google_compute_instance.vm", URI: "main.tf", StartLine: 13, EndLine: 18},
+	}
+
+	if diff := cmp.Diff(want, parseResources(mainTF, "main.tf")); diff != "" {
+		t.Errorf("parseResources() unexpected result (-want, +got): %v", diff)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), mainTF)
+	writeFile(t, filepath.Join(dir, "modules", "net", "network.tf"), "resource \"google_compute_network\" \"vpc\" {\n  name = \"shared\"\n}\n")
+	writeFile(t, filepath.Join(dir, ".terraform", "modules", "copy.tf"), "resource \"google_compute_network\" \"vpc\" {}\n")
+	planPath := filepath.Join(dir, "plan.json")
+	writeFile(t, planPath, modulePlan)
+
+	idx, err := IndexSourceDir(dir)
+	if err != nil {
+		t.Fatalf("IndexSourceDir() failed: %v", err)
+	}
+	if err := idx.AddPlan(planPath); err != nil {
+		t.Fatalf("AddPlan() failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		keys   []string
+		want   Location
+		wantOK bool
+	}{
+		{
+			name:   "Address",
+			keys:   []string{"google_storage_bucket.logs"},
+			want:   Location{Address: "google_storage_bucket.logs", URI: "main.tf", StartLine: 3, EndLine: 9},
+			wantOK: true,
+		},
+		{
+			name:   "ModuleAddressWithInstanceKey",
+			keys:   []string{"module.net.google_compute_network.vpc[0]"},
+			want:   Location{Address: "google_compute_network.vpc", URI: "modules/net/network.tf", StartLine: 1, EndLine: 3},
+			wantOK: true,
+		},
+		{
+			name:   "AssetNameFromPlan",
+			keys:   []string{"//storage.googleapis.com/projects/_/buckets/my-logs"},
+			want:   Location{Address: "google_storage_bucket.logs", URI: "main.tf", StartLine: 3, EndLine: 9},
+			wantOK: true,
+		},
+		{
+			name:   "FallsBackToLaterKey",
+			keys:   []string{"unknown", "", "shared"},
+			want:   Location{Address: "google_compute_network.vpc", URI: "modules/net/network.tf", StartLine: 1, EndLine: 3},
+			wantOK: true,
+		},
+		{
+			name:   "DataSourceNameIgnored",
+			keys:   []string{"my-vm"},
+			want:   Location{Address: "google_compute_instance.vm", URI: "main.tf", StartLine: 13, EndLine: 18},
+			wantOK: true,
+		},
+		{
+			name: "CommentedResourceNotIndexed",
+			keys: []string{"google_storage_bucket.commented"},
+		},
+		{
+			name: "NoMatch",
+			keys: []string{"//compute.googleapis.com/projects/p/zones/z/instances/other"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := idx.Lookup(test.keys...)
+			if ok != test.wantOK {
+				t.Errorf("Lookup(%v) ok = %v, want %v", test.keys, ok, test.wantOK)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Lookup(%v) unexpected result (-want, +got): %v", test.keys, diff)
+			}
+		})
+	}
+}
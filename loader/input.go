@@ -0,0 +1,185 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// STDIN is the file path that makes the loader read from standard input, e.g.
+// to pipe `gcloud ... --format=json` into the tools.
+const STDIN = "-"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ReadInput returns the content of filePath as JSON. Gzip compressed input is
+// decompressed and YAML, gcloud's default output format, is converted to JSON.
+func ReadInput(filePath string) ([]byte, error) {
+	data, err := readFileOrStdin(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		data, err = gunzip(data)
+		if err != nil {
+			return nil, fmt.Errorf("gunzip(%s): %v", filePath, err)
+		}
+	}
+
+	if isJSON(data) {
+		return data, nil
+	}
+
+	data, err = yamlToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("yamlToJSON(%s): %v", filePath, err)
+	}
+
+	return data, nil
+}
+
+func readFileOrStdin(filePath string) ([]byte, error) {
+	if filePath == STDIN {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("io.ReadAll(stdin): %v", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile(%s): %v", filePath, err)
+	}
+	return data, nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gzip.NewReader: %v", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll: %v", err)
+	}
+	return decompressed, nil
+}
+
+// isJSON reports whether data looks like a JSON document. Anything else is
+// treated as YAML, which is a superset of JSON anyway.
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// yamlToJSON converts YAML to JSON. Several documents separated by ---, as
+// gcloud prints them for list and describe of several resources, become a JSON
+// array of the documents, i.e. the list shape.
+func yamlToJSON(data []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	documents := []any{}
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoder.Decode: %v", err)
+		}
+
+		value, err := yamlNodeToValue(&document)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, value)
+	}
+
+	var value any
+	if len(documents) == 1 {
+		value = documents[0]
+	} else if len(documents) > 1 {
+		value = documents
+	}
+
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %v", err)
+	}
+	return jsonData, nil
+}
+
+// yamlNodeToValue converts a YAML node to the value encoding/json would have
+// decoded from the equivalent JSON. Timestamps are kept as written instead of
+// being parsed, since the report models them as strings.
+func yamlNodeToValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeToValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeToValue(node.Alias)
+	case yaml.MappingNode:
+		object := make(map[string]any)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlNodeToValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			object[node.Content[i].Value] = value
+		}
+		return object, nil
+	case yaml.SequenceNode:
+		array := make([]any, 0, len(node.Content))
+		for _, child := range node.Content {
+			value, err := yamlNodeToValue(child)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!str", "!!timestamp", "!!binary":
+			return node.Value, nil
+		case "!!null":
+			return nil, nil
+		default:
+			var value any
+			if err := node.Decode(&value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", node.Line, err)
+			}
+			return value, nil
+		}
+	default:
+		return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const reportJSON = `{"done": true, "response": {"createTime": "2024-05-01T10:00:00Z", "iacValidationReport": {"violations": [{"policyId": "P1", "severity": "HIGH"}]}}}`
+
+const reportYAML = `done: true
+response:
+  createTime: '2024-05-01T10:00:00Z'
+  iacValidationReport:
+    violations:
+    - policyId: P1
+      severity: HIGH
+`
+
+func gzipped(t *testing.T, content string) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("gzip.Write() failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("gzip.Close() failed: %v", err)
+	}
+	return b.Bytes()
+}
+
+func TestReadInput(t *testing.T) {
+	var want any
+	if err := json.Unmarshal([]byte(reportJSON), &want); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "JSON", content: []byte(reportJSON)},
+		{name: "YAML", content: []byte(reportYAML)},
+		{name: "GzipJSON", content: gzipped(t, reportJSON)},
+		{name: "GzipYAML", content: gzipped(t, reportYAML)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "report")
+			if err := os.WriteFile(filePath, test.content, 0644); err != nil {
+				t.Fatalf("os.WriteFile() failed: %v", err)
+			}
+
+			data, err := ReadInput(filePath)
+			if err != nil {
+				t.Fatalf("ReadInput() failed: %v", err)
+			}
+
+			var got any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("json.Unmarshal(%s) failed: %v", data, err)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Unexpected content (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestReadInput_Stdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() failed: %v", err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	if _, err := w.Write([]byte(reportYAML)); err != nil {
+		t.Fatalf("w.Write() failed: %v", err)
+	}
+	w.Close()
+
+	got, err := ReadIACScanReport(STDIN, Options{})
+	if err != nil {
+		t.Fatalf("ReadIACScanReport(STDIN) failed: %v", err)
+	}
+
+	if got.Response.CreateTime != "2024-05-01T10:00:00Z" || len(got.Response.IacValidationReport.Violations) != 1 {
+		t.Errorf("ReadIACScanReport(STDIN) = %+v, want the piped report", got)
+	}
+}
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "UnquotedTimestampKeptAsString",
+			yaml:     "createTime: 2024-05-01T10:00:00.123Z\n",
+			expected: `{"createTime":"2024-05-01T10:00:00.123Z"}`,
+		},
+		{
+			name:     "ScalarTypes",
+			yaml:     "done: true\ncode: 3\nnote: null\nname: '007'\n",
+			expected: `{"code":3,"done":true,"name":"007","note":null}`,
+		},
+		{
+			name:     "Aliases",
+			yaml:     "a: &x [1, 2]\nb: *x\n",
+			expected: `{"a":[1,2],"b":[1,2]}`,
+		},
+		{
+			name:     "MultipleDocuments",
+			yaml:     "name: a\n---\nname: b\n",
+			expected: `[{"name":"a"},{"name":"b"}]`,
+		},
+		{
+			name:    "InvalidSecondDocument",
+			yaml:    "name: a\n---\na: [1, 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid",
+			yaml:    "a: [1, 2\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := yamlToJSON([]byte(test.yaml))
+			if (err != nil) != test.wantErr {
+				t.Errorf("Expected error: %v, got: %v", test.wantErr, err)
+			}
+
+			if diff := cmp.Diff(test.expected, string(got)); diff != "" && !test.wantErr {
+				t.Errorf("Unexpected JSON (-want, +got): %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package loader reads the output of the `gcloud scc iac-validation-reports`
+// commands and makes sure it holds the result of completed scans.
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// Options configures how reports are loaded.
+type Options struct {
+	// Strict rejects reports with unknown fields, wrong types, missing
+	// violation fields or an unsupported API version, instead of silently
+	// ignoring what does not fit templates.IACReportTemplate.
+	Strict bool
+}
+
+// ReadIACScanReport reads the reports at filePath, see ReadIACScanReports, and
+// merges them into a single report.
+func ReadIACScanReport(filePath string, opts Options) (templates.IACReportTemplate, error) {
+	iacReports, err := ReadIACScanReports(filePath, opts)
+	if err != nil {
+		return templates.IACReportTemplate{}, err
+	}
+
+	return Merge(iacReports), nil
+}
+
+// ReadIACScanReports reads and parses the reports at filePath, see ReadInput
+// for the supported inputs. The input can be the operation returned by the
+// create command, a report as returned by the describe command or a list of
+// those as returned by the list command. Every report is normalised to the
+// operation layout of templates.IACReportTemplate.
+//
+// It fails when an operation is still running, ended with an error or has no
+// report, because the report would otherwise look like a scan without
+// violations.
+func ReadIACScanReports(filePath string, opts Options) ([]templates.IACReportTemplate, error) {
+	data, err := ReadInput(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ReadInput: %v", err)
+	}
+
+	return ParseIACScanReports(data, opts)
+}
+
+// ParseIACScanReports is ReadIACScanReports for JSON that was already read,
+// e.g. by callers that need to inspect the input first.
+func ParseIACScanReports(data []byte, opts Options) ([]templates.IACReportTemplate, error) {
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal(): %v", err)
+	}
+
+	iacReports, problems, err := parseDocument(document, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(problems) > 0 {
+		return nil, &SchemaError{Problems: problems}
+	}
+
+	for _, iacReport := range iacReports {
+		if err := CheckOperation(iacReport); err != nil {
+			return nil, err
+		}
+	}
+
+	return iacReports, nil
+}
+
+// Merge combines the violations of several reports into one report. A single
+// report is returned as is.
+func Merge(iacReports []templates.IACReportTemplate) templates.IACReportTemplate {
+	if len(iacReports) == 1 {
+		return iacReports[0]
+	}
+
+	merged := templates.IACReportTemplate{Done: true}
+	notes := []string{}
+
+	for _, iacReport := range iacReports {
+		response := iacReport.Response
+		if merged.Response.CreateTime == "" || (response.CreateTime != "" && response.CreateTime < merged.Response.CreateTime) {
+			merged.Response.CreateTime = response.CreateTime
+		}
+		if response.UpdateTime > merged.Response.UpdateTime {
+			merged.Response.UpdateTime = response.UpdateTime
+		}
+		if response.IacValidationReport.Note != "" {
+			notes = append(notes, response.IacValidationReport.Note)
+		}
+		merged.Response.IacValidationReport.Violations = append(merged.Response.IacValidationReport.Violations, response.IacValidationReport.Violations...)
+	}
+	merged.Response.IacValidationReport.Note = strings.Join(notes, "\n")
+
+	return merged
+}
+
+// CheckOperation returns an error unless the operation completed successfully.
+func CheckOperation(iacReport templates.IACReportTemplate) error {
+	name := iacReport.Name
+	if name == "" {
+		name = "<unnamed>"
+	}
+
+	if iacReport.Error != nil {
+		return fmt.Errorf("IaC validation scan failed: operation %s returned error code %d: %s", name, iacReport.Error.Code, iacReport.Error.Message)
+	}
+
+	if !iacReport.Done {
+		if iacReport.Metadata.StatusMessage != "" {
+			return fmt.Errorf("IaC validation scan did not complete: operation %s is not done: %s", name, iacReport.Metadata.StatusMessage)
+		}
+		return fmt.Errorf("IaC validation scan did not complete: operation %s is not done", name)
+	}
+
+	if iacReport.Metadata.ErrorMessage != "" {
+		return fmt.Errorf("IaC validation scan failed: operation %s: %s", name, iacReport.Metadata.ErrorMessage)
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestReadIACScanReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected templates.IACReportTemplate
+		wantErr  bool
+	}{
+		{
+			name: "DoneOperation_Succeeds",
+			content: `{
+				"name": "operations/op1",
+				"done": true,
+				"metadata": {"verb": "create"},
+				"response": {
+					"name": "reports/r1",
+					"iacValidationReport": {"violations": [{"policyId": "P1", "severity": "HIGH"}]}
+				}
+			}`,
+			expected: templates.IACReportTemplate{
+				Name:     "operations/op1",
+				Done:     true,
+				Metadata: templates.OperationMetadata{Verb: "create"},
+				Response: templates.Responses{
+					Name: "reports/r1",
+					IacValidationReport: templates.IACValidationReport{
+						Violations: []templates.Violation{{PolicyID: "P1", Severity: "HIGH"}},
+					},
+				},
+			},
+		},
+		{
+			name:    "RunningOperation_Failure",
+			content: `{"name": "operations/op1", "done": false, "metadata": {"verb": "create"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "MissingDone_Failure",
+			content: `{"response": {"iacValidationReport": {}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "DoneOperationWithoutResponse_Failure",
+			content: `{"name": "operations/op1", "done": true}`,
+			wantErr: true,
+		},
+		{
+			name:    "DoneOperationWithoutReport_Failure",
+			content: `{"name": "operations/op1", "done": true, "response": {"name": "reports/r1"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "DoneOperationWithEmptyReport_Succeeds",
+			content: `{"name": "operations/op1", "done": true, "response": {"iacValidationReport": {}}}`,
+			expected: templates.IACReportTemplate{
+				Name: "operations/op1",
+				Done: true,
+			},
+		},
+		{
+			name:    "FailedOperation_Failure",
+			content: `{"name": "operations/op1", "done": true, "error": {"code": 3, "message": "invalid plan file"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "InvalidJSON_Failure",
+			content: `{"done": true`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "report.json")
+			if err := os.WriteFile(filePath, []byte(test.content), 0644); err != nil {
+				t.Fatalf("os.WriteFile() failed: %v", err)
+			}
+
+			got, err := ReadIACScanReport(filePath, Options{})
+			if (err != nil) != test.wantErr {
+				t.Errorf("Expected error: %v, got: %v", test.wantErr, err)
+			}
+
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("Unexpected result (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestReadIACScanReport_MissingFile(t *testing.T) {
+	if _, err := ReadIACScanReport(filepath.Join(t.TempDir(), "missing.json"), Options{}); err == nil {
+		t.Errorf("ReadIACScanReport() on missing file succeeded, want error")
+	}
+}
+
+func TestReadIACScanReports(t *testing.T) {
+	violation := templates.Violation{PolicyID: "P1", AssetID: "a1", Severity: "HIGH"}
+	report := templates.Responses{
+		Name: "organizations/1/locations/global/reports/r1",
+		IacValidationReport: templates.IACValidationReport{
+			Violations: []templates.Violation{violation},
+		},
+	}
+	emptyReport := templates.Responses{Name: "organizations/1/locations/global/reports/r2"}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []templates.IACReportTemplate
+		wantErr  bool
+	}{
+		{
+			name:    "Operation",
+			content: `{"done": true, "response": {"name": "organizations/1/locations/global/reports/r1", "iacValidationReport": {"violations": [{"policyId": "P1", "assetId": "a1", "severity": "HIGH"}]}}}`,
+			expected: []templates.IACReportTemplate{
+				{Done: true, Response: report},
+			},
+		},
+		{
+			name:    "BareReport",
+			content: `{"name": "organizations/1/locations/global/reports/r1", "iacValidationReport": {"violations": [{"policyId": "P1", "assetId": "a1", "severity": "HIGH"}]}}`,
+			expected: []templates.IACReportTemplate{
+				{Done: true, Response: report},
+			},
+		},
+		{
+			name:    "BareReportWithoutViolations",
+			content: `{"name": "organizations/1/locations/global/reports/r2"}`,
+			expected: []templates.IACReportTemplate{
+				{Done: true, Response: emptyReport},
+			},
+		},
+		{
+			name: "ListOfReports",
+			content: `[
+				{"name": "organizations/1/locations/global/reports/r1", "iacValidationReport": {"violations": [{"policyId": "P1", "assetId": "a1", "severity": "HIGH"}]}},
+				{"name": "organizations/1/locations/global/reports/r2"}
+			]`,
+			expected: []templates.IACReportTemplate{
+				{Done: true, Response: report},
+				{Done: true, Response: emptyReport},
+			},
+		},
+		{
+			name: "MultipleYAMLDocuments",
+			content: `name: organizations/1/locations/global/reports/r2
+---
+name: organizations/1/locations/global/reports/r1
+iacValidationReport:
+  violations:
+  - policyId: P1
+    assetId: a1
+    severity: HIGH
+`,
+			expected: []templates.IACReportTemplate{
+				{Done: true, Response: emptyReport},
+				{Done: true, Response: report},
+			},
+		},
+		{
+			name:    "ListResponse",
+			content: `{"reports": [{"name": "organizations/1/locations/global/reports/r2"}], "nextPageToken": "t"}`,
+			expected: []templates.IACReportTemplate{
+				{Done: true, Response: emptyReport},
+			},
+		},
+		{
+			name:    "ListWithRunningOperation_Failure",
+			content: `[{"name": "organizations/1/locations/global/reports/r2"}, {"name": "operations/op1", "done": false}]`,
+			wantErr: true,
+		},
+		{
+			name:    "EmptyList_Failure",
+			content: `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "UnrelatedObject_Failure",
+			content: `{"resource_changes": [], "format_version": "1.2"}`,
+			wantErr: true,
+		},
+		{
+			name:    "SarifLog_Failure",
+			content: `{"version": "2.1.0", "runs": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "ListOfUnrelatedValues_Failure",
+			content: `[{"name": "a"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Scalar_Failure",
+			content: `"report"`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "report.json")
+			if err := os.WriteFile(filePath, []byte(test.content), 0644); err != nil {
+				t.Fatalf("os.WriteFile() failed: %v", err)
+			}
+
+			got, err := ReadIACScanReports(filePath, Options{})
+			if (err != nil) != test.wantErr {
+				t.Errorf("Expected error: %v, got: %v", test.wantErr, err)
+			}
+
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("Unexpected result (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	iacReports := []templates.IACReportTemplate{
+		{
+			Done: true,
+			Response: templates.Responses{
+				Name:       "reports/r1",
+				CreateTime: "2024-05-02T00:00:00Z",
+				UpdateTime: "2024-05-02T00:00:00Z",
+				IacValidationReport: templates.IACValidationReport{
+					Note:       "note 1",
+					Violations: []templates.Violation{{PolicyID: "P1"}},
+				},
+			},
+		},
+		{
+			Done: true,
+			Response: templates.Responses{
+				Name:       "reports/r2",
+				CreateTime: "2024-05-01T00:00:00Z",
+				UpdateTime: "2024-05-03T00:00:00Z",
+				IacValidationReport: templates.IACValidationReport{
+					Violations: []templates.Violation{{PolicyID: "P2"}},
+				},
+			},
+		},
+	}
+
+	expected := templates.IACReportTemplate{
+		Done: true,
+		Response: templates.Responses{
+			CreateTime: "2024-05-01T00:00:00Z",
+			UpdateTime: "2024-05-03T00:00:00Z",
+			IacValidationReport: templates.IACValidationReport{
+				Note:       "note 1",
+				Violations: []templates.Violation{{PolicyID: "P1"}, {PolicyID: "P2"}},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(expected, Merge(iacReports)); diff != "" {
+		t.Errorf("Merge() unexpected result (-want, +got): %v", diff)
+	}
+
+	if diff := cmp.Diff(iacReports[0], Merge(iacReports[:1])); diff != "" {
+		t.Errorf("Merge() of a single report unexpected result (-want, +got): %v", diff)
+	}
+}
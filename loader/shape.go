@@ -0,0 +1,209 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+const (
+	// SHAPE_OPERATION is the long-running operation printed by
+	// `gcloud scc iac-validation-reports create`.
+	SHAPE_OPERATION = "operation"
+	// SHAPE_REPORT is the bare report printed by
+	// `gcloud scc iac-validation-reports describe`.
+	SHAPE_REPORT = "report"
+	// SHAPE_LIST is the array printed by `gcloud scc iac-validation-reports
+	// list`. The API list response, an object with a "reports" array, is
+	// accepted as well.
+	SHAPE_LIST = "list"
+	// SHAPE_SARIF is a SARIF log, e.g. the output of SARIFConverter or of
+	// another scanner.
+	SHAPE_SARIF = "sarif"
+	// SHAPE_UNKNOWN is anything else.
+	SHAPE_UNKNOWN = "unknown"
+)
+
+var operationFields = []string{"done", "response", "error", "metadata"}
+
+// DetectShape returns which of the shapes above document, a decoded JSON
+// value, has.
+func DetectShape(document any) string {
+	switch value := document.(type) {
+	case []any:
+		return SHAPE_LIST
+	case map[string]any:
+		for _, field := range operationFields {
+			if _, ok := value[field]; ok {
+				return SHAPE_OPERATION
+			}
+		}
+		if _, ok := value["iacValidationReport"]; ok {
+			return SHAPE_REPORT
+		}
+		if _, ok := value["runs"].([]any); ok {
+			if _, ok := value["version"].(string); ok {
+				return SHAPE_SARIF
+			}
+		}
+		// Reports without violations may omit iacValidationReport
+		// altogether, recognise them by their resource name.
+		if name, _ := value["name"].(string); strings.Contains(name, "/reports/") {
+			return SHAPE_REPORT
+		}
+		if _, ok := value["reports"].([]any); ok {
+			return SHAPE_LIST
+		}
+	}
+	return SHAPE_UNKNOWN
+}
+
+// parseDocument normalises document into operations. Schema problems are only
+// collected in strict mode, an unrecognised shape is always an error.
+func parseDocument(document any, opts Options) ([]templates.IACReportTemplate, []string, error) {
+	switch DetectShape(document) {
+	case SHAPE_OPERATION:
+		iacReport, problems, err := parseOperation("$", document, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []templates.IACReportTemplate{iacReport}, problems, nil
+	case SHAPE_REPORT:
+		iacReport, problems, err := parseReport("$", document, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []templates.IACReportTemplate{iacReport}, problems, nil
+	case SHAPE_LIST:
+		return parseList(document, opts)
+	case SHAPE_SARIF:
+		return nil, nil, fmt.Errorf("unrecognised input: expected an IaC validation operation, report or list of reports, got a SARIF log")
+	default:
+		return nil, nil, fmt.Errorf("unrecognised input: expected an IaC validation operation, report or list of reports, got %s", jsonType(document))
+	}
+}
+
+func parseList(document any, opts Options) ([]templates.IACReportTemplate, []string, error) {
+	path := "$"
+	problems := []string{}
+
+	elements, ok := document.([]any)
+	if !ok {
+		path = "$.reports"
+		object := document.(map[string]any)
+		elements = object["reports"].([]any)
+
+		if opts.Strict {
+			for _, key := range sortedKeys(object) {
+				if key != "reports" && key != "nextPageToken" && key != "unreachable" {
+					problems = append(problems, fmt.Sprintf("$: unknown field %q", key))
+				}
+			}
+		}
+	}
+
+	if len(elements) == 0 {
+		return nil, nil, fmt.Errorf("input is an empty list of reports")
+	}
+
+	iacReports := []templates.IACReportTemplate{}
+
+	for i, element := range elements {
+		elementPath := fmt.Sprintf("%s[%d]", path, i)
+
+		var iacReport templates.IACReportTemplate
+		var elementProblems []string
+		var err error
+
+		switch DetectShape(element) {
+		case SHAPE_OPERATION:
+			iacReport, elementProblems, err = parseOperation(elementPath, element, opts)
+		case SHAPE_REPORT:
+			iacReport, elementProblems, err = parseReport(elementPath, element, opts)
+		default:
+			err = fmt.Errorf("unrecognised input at %s: expected an IaC validation operation or report, got %s", elementPath, jsonType(element))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		iacReports = append(iacReports, iacReport)
+		problems = append(problems, elementProblems...)
+	}
+
+	return iacReports, problems, nil
+}
+
+func parseOperation(path string, document any, opts Options) (templates.IACReportTemplate, []string, error) {
+	var iacReport templates.IACReportTemplate
+	if err := remarshal(document, &iacReport); err != nil && !opts.Strict {
+		return templates.IACReportTemplate{}, nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if opts.Strict {
+		return iacReport, validateOperation(path, document, iacReport), nil
+	}
+
+	// Strict mode reports this as a missing field. Failed operations are left
+	// to CheckOperation, which tells why.
+	if iacReport.Done && iacReport.Error == nil && iacReport.Metadata.ErrorMessage == "" && !hasReport(document) {
+		return templates.IACReportTemplate{}, nil, fmt.Errorf("%s: operation is done but has neither an error nor a response.iacValidationReport", path)
+	}
+	return iacReport, nil, nil
+}
+
+// hasReport reports whether the operation document has a
+// response.iacValidationReport object, which a completed scan always has even
+// without violations.
+func hasReport(document any) bool {
+	root, _ := document.(map[string]any)
+	response, _ := root["response"].(map[string]any)
+	_, ok := response["iacValidationReport"].(map[string]any)
+	return ok
+}
+
+// parseReport wraps a bare report in a completed operation, reports only exist
+// for scans that succeeded.
+func parseReport(path string, document any, opts Options) (templates.IACReportTemplate, []string, error) {
+	var response templates.Responses
+	if err := remarshal(document, &response); err != nil && !opts.Strict {
+		return templates.IACReportTemplate{}, nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	iacReport := templates.IACReportTemplate{Done: true, Response: response}
+	if opts.Strict {
+		return iacReport, validateReport(path, document, iacReport), nil
+	}
+	return iacReport, nil, nil
+}
+
+// remarshal decodes an already decoded JSON value into v. In strict mode type
+// errors are reported by the schema validation with their path instead.
+func remarshal(document any, v any) error {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("json.Marshal(): %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("json.Unmarshal(): %v", err)
+	}
+	return nil
+}
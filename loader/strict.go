@@ -0,0 +1,243 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+// SUPPORTED_API_VERSIONS are the API versions whose report layout matches
+// templates.IACReportTemplate.
+var SUPPORTED_API_VERSIONS = []string{"v1"}
+
+// apiVersionPattern extracts the version from type URLs such as
+// "type.googleapis.com/google.cloud.securityposture.v1.Report".
+var apiVersionPattern = regexp.MustCompile(`google\.cloud\.[a-z]+\.(v[0-9]+(?:alpha[0-9]*|beta[0-9]*)?)\.`)
+
+// requiredViolationFields are the violation fields the tools rely on.
+var requiredViolationFields = []string{"policyId", "severity", "assetId"}
+
+// SchemaError lists every problem found while validating a report in strict
+// mode. Each problem starts with the JSON path it applies to.
+type SchemaError struct {
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("report does not match the IaC validation report schema:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+// DetectAPIVersion returns the API version the report was produced with, or an
+// empty string if the report does not say. The explicit apiVersion of the
+// operation metadata takes precedence over the versions in the type URLs.
+func DetectAPIVersion(iacReport templates.IACReportTemplate) string {
+	if iacReport.Metadata.APIVersion != "" {
+		return iacReport.Metadata.APIVersion
+	}
+
+	for _, typeURL := range []string{iacReport.Response.Type, iacReport.Metadata.Type} {
+		if match := apiVersionPattern.FindStringSubmatch(typeURL); match != nil {
+			return match[1]
+		}
+	}
+
+	return ""
+}
+
+// validateOperation checks document against the layout of
+// templates.IACReportTemplate, rejecting unknown fields and wrong types, and
+// checks that the fields the tools rely on are present.
+func validateOperation(path string, document any, iacReport templates.IACReportTemplate) []string {
+	problems := []string{}
+	validateValue(path, document, reflect.TypeOf(iacReport), &problems)
+
+	// Type problems are already reported above, only look for missing fields
+	// when the overall shape is right. Running and failed operations have no
+	// report, CheckOperation reports those.
+	root, _ := document.(map[string]any)
+	done, _ := root["done"].(bool)
+	_, failed := root["error"]
+	if len(problems) == 0 && done && !failed {
+		response, ok := root["response"].(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: missing field \"response\"", path))
+		} else {
+			problems = append(problems, missingReportFields(childPath(path, "response"), response)...)
+		}
+	}
+
+	return append(problems, checkAPIVersion(path, iacReport)...)
+}
+
+// validateReport is validateOperation for a bare report.
+func validateReport(path string, document any, iacReport templates.IACReportTemplate) []string {
+	problems := []string{}
+	validateValue(path, document, reflect.TypeOf(iacReport.Response), &problems)
+
+	if len(problems) == 0 {
+		problems = append(problems, missingReportFields(path, document.(map[string]any))...)
+	}
+
+	return append(problems, checkAPIVersion(path, iacReport)...)
+}
+
+// missingReportFields reports the fields a report of a completed scan has to
+// have.
+func missingReportFields(path string, response map[string]any) []string {
+	problems := []string{}
+
+	report, ok := response["iacValidationReport"].(map[string]any)
+	if !ok {
+		return append(problems, fmt.Sprintf("%s: missing field \"iacValidationReport\"", path))
+	}
+
+	violations, _ := report["violations"].([]any)
+	for i, v := range violations {
+		violation, _ := v.(map[string]any)
+		for _, field := range requiredViolationFields {
+			if s, _ := violation[field].(string); s == "" {
+				problems = append(problems, fmt.Sprintf("%s.iacValidationReport.violations[%d]: missing field %q", path, i, field))
+			}
+		}
+	}
+
+	return problems
+}
+
+func checkAPIVersion(path string, iacReport templates.IACReportTemplate) []string {
+	version := DetectAPIVersion(iacReport)
+	if version != "" && !isSupportedAPIVersion(version) {
+		return []string{fmt.Sprintf("%s: unsupported API version %s, supported versions are %s", path, version, strings.Join(SUPPORTED_API_VERSIONS, ", "))}
+	}
+	return nil
+}
+
+func validateValue(path string, value any, t reflect.Type, problems *[]string) {
+	if value == nil {
+		return
+	}
+
+	// Details of an operation error are arbitrary protobuf messages.
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		validateValue(path, value, t.Elem(), problems)
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: expected an object, got %s", path, jsonType(value)))
+			return
+		}
+
+		fields := jsonFields(t)
+		for _, key := range sortedKeys(object) {
+			field, ok := fields[key]
+			if !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: unknown field %q", path, key))
+				continue
+			}
+			validateValue(childPath(path, key), object[key], field.Type, problems)
+		}
+	case reflect.Slice:
+		array, ok := value.([]any)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: expected an array, got %s", path, jsonType(value)))
+			return
+		}
+		for i, element := range array {
+			validateValue(fmt.Sprintf("%s[%d]", path, i), element, t.Elem(), problems)
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: expected a string, got %s", path, jsonType(value)))
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: expected a boolean, got %s", path, jsonType(value)))
+		}
+	case reflect.Int:
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			*problems = append(*problems, fmt.Sprintf("%s: expected an integer, got %s", path, jsonType(value)))
+		}
+	}
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func childPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]@ ") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	return path + "." + key
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isSupportedAPIVersion(version string) bool {
+	for _, v := range SUPPORTED_API_VERSIONS {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestStrictProblems(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantProblems []string
+	}{
+		{
+			name: "ValidReport_Succeeds",
+			content: `{
+				"name": "operations/op1",
+				"done": true,
+				"metadata": {"@type": "type.googleapis.com/google.cloud.securityposture.v1.OperationMetadata"},
+				"response": {
+					"@type": "type.googleapis.com/google.cloud.securityposture.v1.Report",
+					"iacValidationReport": {"violations": [{"policyId": "P1", "severity": "HIGH", "assetId": "a1"}]}
+				}
+			}`,
+		},
+		{
+			name:    "NoViolations_Succeeds",
+			content: `{"done": true, "response": {"iacValidationReport": {"note": "no violations"}}}`,
+		},
+		{
+			name:    "RunningOperation_LeftToCheckOperation",
+			content: `{"name": "operations/op1", "done": false}`,
+		},
+		{
+			name:    "UnknownOperationFields_Failure",
+			content: `{"done": true, "resource_changes": [], "response": {"iacValidationReport": {}}}`,
+			wantProblems: []string{
+				`$: unknown field "resource_changes"`,
+			},
+		},
+		{
+			name: "WrongNestingAndTypes_Failure",
+			content: `{
+				"done": true,
+				"response": {
+					"violations": [],
+					"iacValidationReport": {"violations": [{"policyId": 1, "severity": "HIGH", "assetId": "a1", "assetID": "a1"}]}
+				}
+			}`,
+			wantProblems: []string{
+				`$.response.iacValidationReport.violations[0]: unknown field "assetID"`,
+				"$.response.iacValidationReport.violations[0].policyId: expected a string, got a number",
+				`$.response: unknown field "violations"`,
+			},
+		},
+		{
+			name:    "MissingFields_Failure",
+			content: `{"done": true, "response": {"iacValidationReport": {"violations": [{"severity": "HIGH"}, {"policyId": "P1", "severity": "LOW", "assetId": "a1"}]}}}`,
+			wantProblems: []string{
+				`$.response.iacValidationReport.violations[0]: missing field "policyId"`,
+				`$.response.iacValidationReport.violations[0]: missing field "assetId"`,
+			},
+		},
+		{
+			name:    "MissingResponse_Failure",
+			content: `{"done": true}`,
+			wantProblems: []string{
+				`$: missing field "response"`,
+			},
+		},
+		{
+			name:    "UnsupportedVersion_Failure",
+			content: `{"done": true, "response": {"@type": "type.googleapis.com/google.cloud.securityposture.v2.Report", "iacValidationReport": {}}}`,
+			wantProblems: []string{
+				"$: unsupported API version v2, supported versions are v1",
+			},
+		},
+		{
+			name:    "BareReportMissingFields_Failure",
+			content: `{"name": "o/1/locations/global/reports/r1", "iacValidationReport": {"violations": [{"policyId": "P1", "severity": "LOW"}]}}`,
+			wantProblems: []string{
+				`$.iacValidationReport.violations[0]: missing field "assetId"`,
+			},
+		},
+		{
+			name:    "ListProblemsPrefixedWithIndex_Failure",
+			content: `[{"iacValidationReport": {}}, {"iacValidationReport": {"violations": [{"policyId": "P1", "severity": "LOW"}]}, "extra": 1}]`,
+			wantProblems: []string{
+				`$[1]: unknown field "extra"`,
+			},
+		},
+		{
+			name:    "ListResponseUnknownField_Failure",
+			content: `{"reports": [{"iacValidationReport": {}}], "nextPageToken": "t", "total": 1}`,
+			wantProblems: []string{
+				`$: unknown field "total"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var document any
+			if err := json.Unmarshal([]byte(test.content), &document); err != nil {
+				t.Fatalf("json.Unmarshal() failed: %v", err)
+			}
+
+			_, gotProblems, err := parseDocument(document, Options{Strict: true})
+			if err != nil {
+				t.Fatalf("parseDocument() returned unexpected error: %v", err)
+			}
+
+			if len(gotProblems) == 0 {
+				gotProblems = nil
+			}
+			if diff := cmp.Diff(test.wantProblems, gotProblems); diff != "" {
+				t.Errorf("Unexpected problems (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestReadIACScanReport_StrictError(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(filePath, []byte(`{"done": true, "response": {"iacValidationReport": {"violations": [{"severity": "HIGH"}]}}}`), 0644); err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	_, err := ReadIACScanReport(filePath, Options{Strict: true})
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("ReadIACScanReport() = %v, want a SchemaError", err)
+	}
+	if len(schemaErr.Problems) != 2 {
+		t.Errorf("ReadIACScanReport() problems = %v, want 2", schemaErr.Problems)
+	}
+
+	if _, err := ReadIACScanReport(filePath, Options{}); err != nil {
+		t.Errorf("ReadIACScanReport() without strict mode failed: %v", err)
+	}
+}
+
+func TestDetectAPIVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		iacReport templates.IACReportTemplate
+		expected  string
+	}{
+		{
+			name:      "NoTypeInformation",
+			iacReport: templates.IACReportTemplate{},
+			expected:  "",
+		},
+		{
+			name: "FromResponseType",
+			iacReport: templates.IACReportTemplate{
+				Response: templates.Responses{Type: "type.googleapis.com/google.cloud.securityposture.v1.Report"},
+			},
+			expected: "v1",
+		},
+		{
+			name: "FromMetadataType",
+			iacReport: templates.IACReportTemplate{
+				Metadata: templates.OperationMetadata{Type: "type.googleapis.com/google.cloud.securityposture.v1alpha.OperationMetadata"},
+			},
+			expected: "v1alpha",
+		},
+		{
+			name: "ExplicitVersionWins",
+			iacReport: templates.IACReportTemplate{
+				Metadata: templates.OperationMetadata{APIVersion: "v1beta", Type: "type.googleapis.com/google.cloud.securityposture.v1.OperationMetadata"},
+			},
+			expected: "v1beta",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DetectAPIVersion(test.iacReport); got != test.expected {
+				t.Errorf("DetectAPIVersion() = %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// package severity normalises the severities found in IaC validation reports
+// and decides what happens with severities the tools do not know.
+package severity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+const (
+	CRITICAL = "CRITICAL"
+	HIGH     = "HIGH"
+	MEDIUM   = "MEDIUM"
+	LOW      = "LOW"
+	// UNKNOWN is the bucket unrecognised severities are counted under when
+	// the policy mode is MODE_UNKNOWN.
+	UNKNOWN = "UNKNOWN"
+)
+
+const (
+	// MODE_FAIL rejects any severity outside CRITICAL, HIGH, MEDIUM and LOW.
+	MODE_FAIL = "fail"
+	// MODE_MAP replaces unrecognised severities with Policy.MapTo.
+	MODE_MAP = "map"
+	// MODE_UNKNOWN replaces unrecognised severities with UNKNOWN.
+	MODE_UNKNOWN = "unknown"
+)
+
+// Known lists the severities reported by SCC, from most to least severe.
+var Known = []string{CRITICAL, HIGH, MEDIUM, LOW}
+
+// All lists every severity a normalised violation can have, from most to
+// least severe.
+var All = []string{CRITICAL, HIGH, MEDIUM, LOW, UNKNOWN}
+
+// Policy decides how unrecognised severities are handled. The zero value
+// fails on them.
+type Policy struct {
+	Mode  string
+	MapTo string
+}
+
+// ParsePolicy parses the value of the unknown_severity flag: "fail",
+// "unknown" or "map:<SEVERITY>".
+func ParsePolicy(value string) (Policy, error) {
+	mode, target, hasTarget := strings.Cut(strings.TrimSpace(value), ":")
+	mode = strings.ToLower(mode)
+
+	switch mode {
+	case "", MODE_FAIL:
+		if hasTarget {
+			return Policy{}, fmt.Errorf("unexpected severity in policy: %s", value)
+		}
+		return Policy{Mode: MODE_FAIL}, nil
+	case MODE_UNKNOWN:
+		if hasTarget {
+			return Policy{}, fmt.Errorf("unexpected severity in policy: %s", value)
+		}
+		return Policy{Mode: MODE_UNKNOWN}, nil
+	case MODE_MAP:
+		target = strings.ToUpper(strings.TrimSpace(target))
+		if !IsKnown(target) {
+			return Policy{}, fmt.Errorf("invalid severity to map to: %s", target)
+		}
+		return Policy{Mode: MODE_MAP, MapTo: target}, nil
+	default:
+		return Policy{}, fmt.Errorf("invalid unknown severity policy: %s", value)
+	}
+}
+
+// IsKnown reports whether severity is one of Known. It expects an already
+// upper-cased value.
+func IsKnown(severity string) bool {
+	for _, s := range Known {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize upper-cases severity, strips the "SEVERITY_" enum prefix newer API
+// versions may use and applies policy to values that are not Known.
+func Normalize(severity string, policy Policy) (string, error) {
+	s := strings.ToUpper(strings.TrimSpace(severity))
+	s = strings.TrimPrefix(s, "SEVERITY_")
+
+	if IsKnown(s) {
+		return s, nil
+	}
+
+	switch policy.Mode {
+	case MODE_MAP:
+		return policy.MapTo, nil
+	case MODE_UNKNOWN:
+		return UNKNOWN, nil
+	default:
+		return "", fmt.Errorf("invalid severity: %s", severity)
+	}
+}
+
+// NormalizeViolations returns a copy of violations with every severity
+// normalised according to policy.
+func NormalizeViolations(violations []templates.Violation, policy Policy) ([]templates.Violation, error) {
+	normalized := make([]templates.Violation, len(violations))
+
+	for i, v := range violations {
+		s, err := Normalize(v.Severity, policy)
+		if err != nil {
+			return nil, fmt.Errorf("violation of policy %s on asset %s: %v", v.PolicyID, v.AssetID, err)
+		}
+		v.Severity = s
+		normalized[i] = v
+	}
+
+	return normalized, nil
+}
+
+// Rank returns the position of severity in All, so that sorting by rank
+// orders violations from most to least severe.
+func Rank(severity string) int {
+	for i, s := range All {
+		if s == severity {
+			return i
+		}
+	}
+	return len(All)
+}
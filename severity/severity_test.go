@@ -0,0 +1,173 @@
+/*
+ Copyright 2024 Google LLC
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      https://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package severity
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/gcp-scc-iac-validation-utils/templates"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  Policy
+		wantError bool
+	}{
+		{
+			name:     "Empty_DefaultsToFail",
+			value:    "",
+			expected: Policy{Mode: MODE_FAIL},
+		},
+		{
+			name:     "Unknown_Succeeds",
+			value:    "UNKNOWN",
+			expected: Policy{Mode: MODE_UNKNOWN},
+		},
+		{
+			name:     "MapLowercase_Succeeds",
+			value:    "map:low",
+			expected: Policy{Mode: MODE_MAP, MapTo: LOW},
+		},
+		{
+			name:      "MapToUnknownSeverity_Failure",
+			value:     "map:severe",
+			wantError: true,
+		},
+		{
+			name:      "MapWithoutSeverity_Failure",
+			value:     "map",
+			wantError: true,
+		},
+		{
+			name:      "FailWithSeverity_Failure",
+			value:     "fail:HIGH",
+			wantError: true,
+		},
+		{
+			name:      "InvalidMode_Failure",
+			value:     "ignore",
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParsePolicy(test.value)
+			if (err != nil) != test.wantError {
+				t.Errorf("Expected error: %v, got: %v", test.wantError, err)
+			}
+
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("Unexpected policy (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		severity  string
+		policy    Policy
+		expected  string
+		wantError bool
+	}{
+		{
+			name:     "Known_Succeeds",
+			severity: "HIGH",
+			expected: HIGH,
+		},
+		{
+			name:     "Lowercase_Succeeds",
+			severity: " critical ",
+			expected: CRITICAL,
+		},
+		{
+			name:     "EnumPrefix_Succeeds",
+			severity: "SEVERITY_LOW",
+			expected: LOW,
+		},
+		{
+			name:      "Unspecified_FailPolicy_Failure",
+			severity:  "SEVERITY_UNSPECIFIED",
+			policy:    Policy{Mode: MODE_FAIL},
+			wantError: true,
+		},
+		{
+			name:      "Empty_ZeroPolicy_Failure",
+			severity:  "",
+			wantError: true,
+		},
+		{
+			name:     "Unspecified_MapPolicy_Succeeds",
+			severity: "SEVERITY_UNSPECIFIED",
+			policy:   Policy{Mode: MODE_MAP, MapTo: MEDIUM},
+			expected: MEDIUM,
+		},
+		{
+			name:     "Unspecified_UnknownPolicy_Succeeds",
+			severity: "SEVERITY_UNSPECIFIED",
+			policy:   Policy{Mode: MODE_UNKNOWN},
+			expected: UNKNOWN,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Normalize(test.severity, test.policy)
+			if (err != nil) != test.wantError {
+				t.Errorf("Expected error: %v, got: %v", test.wantError, err)
+			}
+
+			if got != test.expected {
+				t.Errorf("Expected severity: %v, got: %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeViolations(t *testing.T) {
+	violations := []templates.Violation{
+		{PolicyID: "P1", Severity: "high"},
+		{PolicyID: "P2", Severity: "SEVERITY_UNSPECIFIED"},
+	}
+
+	got, err := NormalizeViolations(violations, Policy{Mode: MODE_UNKNOWN})
+	if err != nil {
+		t.Fatalf("NormalizeViolations() failed: %v", err)
+	}
+
+	expected := []templates.Violation{
+		{PolicyID: "P1", Severity: HIGH},
+		{PolicyID: "P2", Severity: UNKNOWN},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Unexpected violations (-want, +got): %v", diff)
+	}
+
+	if violations[0].Severity != "high" {
+		t.Errorf("NormalizeViolations() modified its input")
+	}
+
+	if _, err := NormalizeViolations(violations, Policy{}); err == nil {
+		t.Errorf("NormalizeViolations() with fail policy succeeded, want error")
+	}
+}
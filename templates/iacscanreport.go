@@ -16,12 +16,40 @@
 
 package templates
 
+import "encoding/json"
+
 // IACReportTemplate is the SCC IAC validation report template passed as an input.
+// It is the long-running operation returned by the create command, whose
+// response only holds the report once the operation is done without error.
 type IACReportTemplate struct {
-	Response Responses `json:"response,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Done     bool              `json:"done,omitempty"`
+	Error    *OperationError   `json:"error,omitempty"`
+	Metadata OperationMetadata `json:"metadata,omitempty"`
+	Response Responses         `json:"response,omitempty"`
+}
+
+// OperationError is the google.rpc.Status set on a failed operation.
+type OperationError struct {
+	Code    int               `json:"code,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+type OperationMetadata struct {
+	Type                  string `json:"@type,omitempty"`
+	CreateTime            string `json:"createTime,omitempty"`
+	EndTime               string `json:"endTime,omitempty"`
+	Target                string `json:"target,omitempty"`
+	Verb                  string `json:"verb,omitempty"`
+	StatusMessage         string `json:"statusMessage,omitempty"`
+	RequestedCancellation bool   `json:"requestedCancellation,omitempty"`
+	APIVersion            string `json:"apiVersion,omitempty"`
+	ErrorMessage          string `json:"errorMessage,omitempty"`
 }
 
 type Responses struct {
+	Type                string              `json:"@type,omitempty"`
 	Name                string              `json:"name,omitempty"`
 	CreateTime          string              `json:"createTime,omitempty"`
 	UpdateTime          string              `json:"updateTime,omitempty"`
@@ -26,12 +26,62 @@ type SarifOutput struct {
 }
 
 type Run struct {
-	Tool    Tool     `json:"tool,omitempty"`
-	Results []Result `json:"results,omitempty"`
+	Tool               Tool                        `json:"tool,omitempty"`
+	Invocations        []Invocation                `json:"invocations,omitempty"`
+	AutomationDetails  *AutomationDetails          `json:"automationDetails,omitempty"`
+	OriginalURIBaseIDs map[string]ArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Taxonomies         []ToolComponent             `json:"taxonomies,omitempty"`
+	Results            []Result                    `json:"results"`
+	Properties         RunProperties               `json:"properties,omitempty"`
+}
+
+type Invocation struct {
+	ExecutionSuccessful        bool           `json:"executionSuccessful"`
+	StartTimeUTC               string         `json:"startTimeUtc,omitempty"`
+	EndTimeUTC                 string         `json:"endTimeUtc,omitempty"`
+	ToolExecutionNotifications []Notification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type Notification struct {
+	Level   string  `json:"level,omitempty"`
+	Message Message `json:"message"`
+}
+
+type AutomationDetails struct {
+	ID string `json:"id,omitempty"`
+}
+
+type RunProperties struct {
+	ReportName      string         `json:"reportName,omitempty"`
+	SeverityCounts  map[string]int `json:"severityCounts,omitempty"`
+	TotalViolations int            `json:"totalViolations"`
+	// DroppedResults counts the results left out to stay within the result
+	// limits.
+	DroppedResults int `json:"droppedResults,omitempty"`
+}
+
+// ToolComponent is a taxonomy, e.g. a compliance standard, or an extension
+// of the tool, e.g. a posture.
+type ToolComponent struct {
+	Name       string                   `json:"name"`
+	FullName   string                   `json:"fullName,omitempty"`
+	Version    string                   `json:"version,omitempty"`
+	Taxa       []Taxon                  `json:"taxa,omitempty"`
+	Rules      []Rule                   `json:"rules,omitempty"`
+	Properties *ToolComponentProperties `json:"properties,omitempty"`
+}
+
+type ToolComponentProperties struct {
+	PolicySets []string `json:"policySets,omitempty"`
+}
+
+type Taxon struct {
+	ID string `json:"id"`
 }
 
 type Tool struct {
-	Driver Driver `json:"driver,omitempty"`
+	Driver     Driver          `json:"driver,omitempty"`
+	Extensions []ToolComponent `json:"extensions,omitempty"`
 }
 
 type Driver struct {
@@ -42,15 +92,50 @@ type Driver struct {
 }
 
 type Rule struct {
-	ID              string          `json:"id,omitempty"`
-	FullDescription FullDescription `json:"fullDescription"`
-	Properties      RuleProperties  `json:"properties,omitempty"`
+	ID                   string               `json:"id,omitempty"`
+	Name                 string               `json:"name,omitempty"`
+	ShortDescription     ShortDescription     `json:"shortDescription"`
+	FullDescription      FullDescription      `json:"fullDescription"`
+	Help                 *Help                `json:"help,omitempty"`
+	HelpURI              string               `json:"helpUri,omitempty"`
+	DefaultConfiguration DefaultConfiguration `json:"defaultConfiguration,omitempty"`
+	Relationships        []Relationship       `json:"relationships,omitempty"`
+	Properties           RuleProperties       `json:"properties,omitempty"`
+}
+
+type Relationship struct {
+	Target DescriptorReference `json:"target"`
+	Kinds  []string            `json:"kinds,omitempty"`
+}
+
+type DescriptorReference struct {
+	ID            string                 `json:"id,omitempty"`
+	Index         *int                   `json:"index,omitempty"`
+	ToolComponent ToolComponentReference `json:"toolComponent,omitempty"`
+}
+
+type ToolComponentReference struct {
+	Name  string `json:"name,omitempty"`
+	Index *int   `json:"index,omitempty"`
+}
+
+type DefaultConfiguration struct {
+	Level string `json:"level,omitempty"`
+}
+
+type ShortDescription struct {
+	Text string `json:"text"`
 }
 
 type FullDescription struct {
 	Text string `json:"text"`
 }
 
+type Help struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
 type RuleProperties struct {
 	Severity            string   `json:"severity,omitempty"`
 	PolicyType          string   `json:"policyType,omitempty"`
@@ -61,13 +146,54 @@ type RuleProperties struct {
 	PostureDeploymentID string   `json:"postureDeploymentId,omitempty"`
 	Constraints         string   `json:"constraints,omitempty"`
 	NextSteps           string   `json:"nextSteps,omitempty"`
+	SecuritySeverity    string   `json:"security-severity,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
 }
 
 type Result struct {
-	RuleID     string           `json:"ruleId,omitempty"`
-	Message    Message          `json:"message,omitempty"`
-	Locations  []Location       `json:"locations,omitempty"`
-	Properties ResultProperties `json:"properties,omitempty"`
+	RuleID              string               `json:"ruleId,omitempty"`
+	RuleIndex           int                  `json:"ruleIndex"`
+	Rule                *DescriptorReference `json:"rule,omitempty"`
+	Level               string               `json:"level,omitempty"`
+	Message             Message              `json:"message,omitempty"`
+	Locations           []Location           `json:"locations,omitempty"`
+	Fixes               []Fix                `json:"fixes,omitempty"`
+	Fingerprints        map[string]string    `json:"fingerprints,omitempty"`
+	PartialFingerprints map[string]string    `json:"partialFingerprints,omitempty"`
+	Suppressions        []Suppression        `json:"suppressions,omitempty"`
+	BaselineState       string               `json:"baselineState,omitempty"`
+	Properties          ResultProperties     `json:"properties,omitempty"`
+}
+
+type Fix struct {
+	Description     Message          `json:"description,omitempty"`
+	ArtifactChanges []ArtifactChange `json:"artifactChanges"`
+}
+
+type ArtifactChange struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Replacements     []Replacement    `json:"replacements"`
+}
+
+type Replacement struct {
+	DeletedRegion   Region           `json:"deletedRegion"`
+	InsertedContent *ArtifactContent `json:"insertedContent,omitempty"`
+}
+
+type ArtifactContent struct {
+	Text string `json:"text"`
+}
+
+type Suppression struct {
+	Kind          string                `json:"kind"`
+	Status        string                `json:"status,omitempty"`
+	Justification string                `json:"justification,omitempty"`
+	Properties    SuppressionProperties `json:"properties,omitempty"`
+}
+
+type SuppressionProperties struct {
+	Owner   string `json:"owner,omitempty"`
+	Expires string `json:"expires,omitempty"`
 }
 
 type Message struct {
@@ -75,9 +201,27 @@ type Message struct {
 }
 
 type Location struct {
+	PhysicalLocation *PhysicalLocation  `json:"physicalLocation,omitempty"`
 	LogicalLocations []LogicalLocations `json:"logicalLocations,omitempty"`
 }
 
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           Region           `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI       string `json:"uri,omitempty"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
 type LogicalLocations struct {
 	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
 }
@@ -86,4 +230,7 @@ type ResultProperties struct {
 	AssetID   string `json:"assetId,omitempty"`
 	AssetType string `json:"assetType,omitempty"`
 	Asset     string `json:"asset,omitempty"`
+	// Severity is the severity of the violation, which can differ from the
+	// severity of its rule, taken from the first violation of the policy.
+	Severity string `json:"severity,omitempty"`
 }